@@ -2,15 +2,14 @@ package flowconf
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
 
-	"github.com/BurntSushi/toml"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -29,12 +28,22 @@ func SetBuilderWorkers(n int32) {
 }
 
 type Builder struct {
-	sources  []*StaticSource
-	managers []SecretManager
+	sources         []Source
+	managers        []SecretManager
+	onMissingSource func(name string)
+	strict          bool
+	onUnknownKey    func(source string, key string)
+	beforeDecode    []namedHook
+	afterMerge      []namedHook
+	afterSecrets    []namedHook
+	schema          *jsonschema.Schema
+
+	mu         sync.Mutex
+	provenance map[string]Origin
 }
 
-func NewBuilder(staticSources ...*StaticSource) *Builder {
-	return &Builder{sources: staticSources}
+func NewBuilder(sources ...Source) *Builder {
+	return &Builder{sources: sources}
 }
 
 func (builder *Builder) Build(config any) error {
@@ -45,72 +54,203 @@ func (builder *Builder) SetSecretManagers(managers ...SecretManager) {
 	builder.managers = managers
 }
 
+// SetMissingSourceHook sets a function called with the name of every optional
+// source skipped because it does not exist
+func (builder *Builder) SetMissingSourceHook(hook func(name string)) {
+	builder.onMissingSource = hook
+}
+
+// SetStrict makes the build fail with an *UnknownKeysError when the sources set
+// keys matching no field of the configuration, like a typo in a file.
+//
+// The keys are checked for the TOML, JSON, YAML, dotenv and environment sources.
+// An environment source without a prefix is not suited to strict mode, all the
+// unrelated variables are unknown keys
+func (builder *Builder) SetStrict(strict bool) {
+	builder.strict = strict
+}
+
+// SetUnknownKeyHook sets a function called with the source name and the full
+// path of every key matching no field of the configuration, the build does not
+// fail unless the Builder is strict
+func (builder *Builder) SetUnknownKeyHook(hook func(source string, key string)) {
+	builder.onUnknownKey = hook
+}
+
+// SetSchema validates the sources against the JSON Schema while building, the
+// build fails with a *SchemaError listing every violated rule.
+//
+// The schema names the keys like the JSON decoder, like the schema generated by
+// GenerateSchema for Json. The keys of the TOML and YAML sources are renamed
+// after the fields they set, and their durations are read in nanoseconds,
+// before being validated, without the required properties as a source can set
+// a part of the configuration only. The merged
+// configuration, encoded in JSON before the secrets are resolved, is validated
+// with the required properties.
+func (builder *Builder) SetSchema(schema []byte) error {
+	s, err := compileSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	builder.schema = s
+	return nil
+}
+
 func (builder *Builder) BuildCtx(ctx context.Context, config any) error {
 	err := checkIfConfigIsValid(config)
 	if err != nil {
 		return err
 	}
 
-	err = buildFromSources(config, builder.sources)
+	provenance := map[string]Origin{}
+	defer builder.setProvenance(provenance)
+
+	defaults, err := applyDefaults(reflect.ValueOf(config), "", map[reflect.Type]bool{})
+	if err != nil {
+		return err
+	}
+	for _, path := range defaults {
+		record(provenance, path, DefaultSource)
+	}
+
+	err = runHooks(ctx, config, builder.beforeDecode)
+	if err != nil {
+		return err
+	}
+
+	consulted, err := builder.buildFromSources(ctx, config, provenance)
+	if err != nil {
+		return err
+	}
+
+	err = runHooks(ctx, config, builder.afterMerge)
 	if err != nil {
 		return err
 	}
 
 	if len(builder.managers) > 0 {
-		return resolveSecrets(ctx, config, builder.managers)
+		recordSecrets(provenance, findReferences(reflect.ValueOf(config), ""))
+
+		err = resolveSecrets(ctx, config, builder.managers)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = runHooks(ctx, config, builder.afterSecrets)
+	if err != nil {
+		return err
+	}
+
+	if missing := missingFields(reflect.ValueOf(config), ""); len(missing) > 0 {
+		return &MissingFieldsError{Fields: missing, Sources: consulted}
+	}
+
+	violations, err := validate(reflect.ValueOf(config), "", true)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
 	}
 
 	return nil
 }
 
-func buildFromSources(config any, sources []*StaticSource) error {
-	var err error
-	for _, source := range sources {
-
-		switch source.format {
-		case Toml:
-			err = parseTOML(config, source.reader)
-		case Json:
-			err = parseJSON(config, source.reader)
-		default:
-			err = fmt.Errorf("unsupported format: %s", source.format)
+// buildFromSources decodes the sources into the config, recording the origin
+// of the fields, it returns the name of every source that was decoded
+func (builder *Builder) buildFromSources(
+	ctx context.Context,
+	config any,
+	provenance map[string]Origin,
+) ([]string, error) {
+	var (
+		consulted   []string
+		unknownKeys []UnknownKey
+		violations  []SchemaViolation
+	)
+	for _, source := range builder.sources {
+		docs, err := loadDocuments(ctx, source)
+		if errors.Is(err, MissingSourceErr) {
+			if builder.onMissingSource != nil {
+				builder.onMissingSource(source.Name())
+			}
+			continue
 		}
-
 		if err != nil {
-			return fmt.Errorf(
-				"failed to process source: %s, %w",
-				source.name,
-				err,
-			)
+			return nil, err
 		}
 
-		err = source.reader.Close()
-		if err != nil {
-			return fmt.Errorf(
-				"failed to close source: %s, %s",
-				source.name,
-				err,
-			)
+		for _, doc := range docs {
+			consulted = append(consulted, doc.name())
+
+			if builder.schema != nil {
+				found, err := validateDocument(builder.schema, doc, reflect.TypeOf(config))
+				if err != nil {
+					return nil, fmt.Errorf("failed to validate source: %s, %w", doc.name(), err)
+				}
+				if len(found) > 0 {
+					// the document is not decoded, its violations are reported
+					// with the others
+					violations = append(violations, found...)
+					continue
+				}
+			}
+
+			keys, err := doc.keys(reflect.TypeOf(config))
+			if err == nil {
+				err = doc.apply(config, keys)
+			}
+			if err != nil {
+				return nil, fmt.Errorf(
+					"failed to process source: %s, %w",
+					doc.name(),
+					err,
+				)
+			}
+
+			for path := range replacedMaps(reflect.TypeOf(config), keys) {
+				forget(provenance, path)
+			}
+			for _, key := range keys {
+				if key.path != nil {
+					record(provenance, strings.Join(key.path, "."), doc.name())
+					continue
+				}
+				if doc.isInclude(key) {
+					continue
+				}
+
+				unknownKeys = append(unknownKeys, UnknownKey{Source: doc.name(), Key: key.key})
+				if builder.onUnknownKey != nil {
+					builder.onUnknownKey(doc.name(), key.key)
+				}
+			}
 		}
 	}
 
-	return nil
-}
-
-func parseTOML(config any, r io.Reader) error {
-	_, err := toml.NewDecoder(r).Decode(config)
-	return err
-}
+	if builder.strict && len(unknownKeys) > 0 {
+		return nil, &UnknownKeysError{Keys: unknownKeys}
+	}
 
-func parseJSON(config any, r io.Reader) error {
-	return json.NewDecoder(r).Decode(config)
-}
+	if builder.schema != nil && len(violations) == 0 {
+		found, err := validateConfig(builder.schema, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate the merged configuration, %w", err)
+		}
+		violations = append(violations, found...)
+	}
+	if len(violations) > 0 {
+		return nil, &SchemaError{Violations: violations}
+	}
 
-type replacement struct {
-	old string
-	new string
+	return consulted, nil
 }
 
+// resolveSecrets replaces the references to a secret of the strings and the
+// Secret values of the configuration, the other values are left untouched.
+// The values are set once every reference is resolved
 func resolveSecrets(
 	ctx context.Context,
 	config any,
@@ -119,102 +259,34 @@ func resolveSecrets(
 	g, ctx := errgroup.WithContext(ctx)
 	g.SetLimit(int(builderWorkers.Load()))
 
-	var mu sync.Mutex
-
-	strConfig, err := configToJSON(config)
-	if err != nil {
-		return err
-	}
-
-	subs := findSubstitutions(strConfig)
-	replacements := make([]replacement, 0, len(subs))
-	for _, sub := range subs {
-		sub := sub
-		manager, err := getManagerForPrefix(sub.managerPrefix, managers)
-		if err != nil {
-			return err
-		}
-
+	values := findSecretValues(reflect.ValueOf(config), func() {})
+	resolved := make([]string, len(values))
+	for i, value := range values {
+		i, value := i, value
 		g.Go(func() error {
 			select {
 			case <-ctx.Done():
-				return nil
+				return ctx.Err()
 			default:
 			}
 
-			secret, err := manager.Secret(ctx, sub.managerKey)
-			if err != nil {
-				return err
-			}
-
-			escapedSecret := escape(secret)
-
-			mu.Lock()
-			replacements = append(
-				replacements,
-				replacement{old: sub.value, new: escapedSecret},
-			)
-			mu.Unlock()
-			return nil
+			var err error
+			resolved[i], err = resolveReferences(ctx, value.value, managers)
+			return err
 		})
 	}
 
+	// the values are only set once every reference is resolved, a failed or
+	// cancelled build leaves the references in place
 	if err := g.Wait(); err != nil {
 		return err
 	}
 
-	// actually do the replacement
-	for _, r := range replacements {
-		strConfig = strings.Replace(strConfig, r.old, r.new, 1)
+	for i, value := range values {
+		value.set(resolved[i])
 	}
 
-	return parseJSON(config, strings.NewReader(strConfig))
-}
-
-func escape(str string) string {
-	str = strings.ReplaceAll(str, "\n", "\\n")
-	return strings.ReplaceAll(str, `"`, `\"`)
-}
-
-func configToJSON(config any) (string, error) {
-	confb, err := json.Marshal(config)
-	if err != nil {
-		return "", fmt.Errorf(
-			"failed to marshal config in order to resolve secrets, %w", err,
-		)
-	}
-
-	return string(confb), nil
-}
-
-type substitutions struct {
-	value         string
-	managerPrefix string
-	managerKey    string
-}
-
-func findSubstitutions(str string) []substitutions {
-	var out []substitutions
-
-	matches := managerReg.FindAllStringSubmatch(str, -1)
-	for i := range matches {
-		if len(matches[i]) != 3 {
-			continue
-		}
-		if strings.HasPrefix(matches[i][0], "\\") {
-			continue
-		}
-
-		out = append(
-			out, substitutions{
-				value:         matches[i][0],
-				managerPrefix: matches[i][1],
-				managerKey:    matches[i][2],
-			},
-		)
-	}
-
-	return out
+	return nil
 }
 
 func getManagerForPrefix(
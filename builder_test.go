@@ -1,6 +1,12 @@
 package flowconf_test
 
 import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,14 +19,14 @@ import (
 func TestBuilder_Build_fromStaticSource(t *testing.T) {
 	tests := []struct {
 		name    string
-		sources []*flowconf.StaticSource
+		sources []flowconf.Source
 		config  any
 		want    any
 		wantErr assert.ErrorAssertionFunc
 	}{
 		{
 			name: "one source toml",
-			sources: func() []*flowconf.StaticSource {
+			sources: func() []flowconf.Source {
 				sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(
 					test.FileSystem,
 					"data/config.toml",
@@ -46,7 +52,7 @@ func TestBuilder_Build_fromStaticSource(t *testing.T) {
 		},
 		{
 			name: "one source json",
-			sources: func() []*flowconf.StaticSource {
+			sources: func() []flowconf.Source {
 				sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(
 					test.FileSystem,
 					"data/config.json",
@@ -70,6 +76,84 @@ func TestBuilder_Build_fromStaticSource(t *testing.T) {
 			},
 			wantErr: assert.NoError,
 		},
+		{
+			name: "one source yaml",
+			sources: func() []flowconf.Source {
+				sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(
+					test.FileSystem,
+					"data/config.yaml",
+				)
+				if err != nil {
+					t.Fatalf("failed to load sources from embedded filesystem")
+				}
+				return sources
+			}(),
+			config: new(test.Config),
+			// values are set in the [[test/data/config.yaml]] file
+			want: &test.Config{
+				MeaningOfLife:   42,
+				Cats:            []string{"James", "Bond"},
+				Pi:              3.14,
+				Perfection:      []int{6, 28, 496, 8128},
+				BackToTheFuture: time.Date(1985, 10, 21, 1, 22, 0, 0, time.UTC),
+				// no manager here so we should get the value
+				Secret: "@managerprefix::projects/id/secrets/name-of-secret",
+				Tag:    "this should be resolved with the yaml tag",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "one source dotenv",
+			sources: func() []flowconf.Source {
+				sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(
+					test.FileSystem,
+					"data/config.env",
+				)
+				if err != nil {
+					t.Fatalf("failed to load sources from embedded filesystem")
+				}
+				return sources
+			}(),
+			config: new(test.Config),
+			// values are set in the [[test/data/config.env]] file
+			want: &test.Config{
+				MeaningOfLife:   42,
+				Cats:            []string{"James", "Bond"},
+				Pi:              3.14,
+				Perfection:      []int{6, 28, 496, 8128},
+				BackToTheFuture: time.Date(1985, 10, 21, 1, 22, 0, 0, time.UTC),
+				// no manager here so we should get the value
+				Secret: "@managerprefix::projects/id/secrets/name-of-secret",
+				Tag:    "this should be resolved with the dotenv tag",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "yaml documents are applied in order",
+			sources: func() []flowconf.Source {
+				sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(
+					test.FileSystem,
+					"data/config.toml",
+					"data/config-override-three.yml",
+				)
+				if err != nil {
+					t.Fatalf("failed to load sources from embedded filesystem")
+				}
+				return sources
+			}(),
+			config: new(test.Config),
+			// values are set in the [[test/data/*]] file
+			want: &test.Config{
+				MeaningOfLife:   42,
+				Cats:            []string{"Bob", "Morane"},
+				Pi:              3.14,
+				Perfection:      []int{6, 28, 496, 8128},
+				BackToTheFuture: time.Date(1985, 10, 21, 1, 22, 0, 0, time.UTC),
+				Secret:          "secret in the second yaml document",
+				Tag:             "this should be resolved with the toml tag",
+			},
+			wantErr: assert.NoError,
+		},
 		{
 			name:    "error if the config is not a pointer",
 			sources: nil,
@@ -96,7 +180,7 @@ func TestBuilder_Build_fromStaticSource(t *testing.T) {
 		},
 		{
 			name: "last source will override previous sources",
-			sources: func() []*flowconf.StaticSource {
+			sources: func() []flowconf.Source {
 				sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(
 					test.FileSystem,
 					"data/config.toml",
@@ -180,3 +264,218 @@ func TestBuilder_Build_fetchesSecretsFromSecretsManagers(t *testing.T) {
 	assert.EqualValues(t, want, config)
 	managerMock.AssertExpectations(t)
 }
+
+func TestBuilder_Build_loadsSourcesAtBuildTime(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		loaded int
+		source = flowconf.NewLazySource(
+			"lazy.json",
+			func(_ context.Context) (flowconf.Format, io.ReadCloser, error) {
+				loaded++
+				return flowconf.Json, io.NopCloser(strings.NewReader(`{"MeaningOfLife": 42}`)), nil
+			},
+		)
+		config = new(test.Config)
+	)
+
+	builder := flowconf.NewBuilder(source)
+	assert.Equal(t, 0, loaded)
+
+	// /////////////////////// WHEN ///////////////////////
+	err := builder.Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, 1, loaded)
+	assert.Equal(t, 42, config.MeaningOfLife)
+}
+
+func TestBuilder_Build_failsWhenASourceCannotBeLoaded(t *testing.T) {
+	source := flowconf.NewLazySource(
+		"remote.json",
+		func(_ context.Context) (flowconf.Format, io.ReadCloser, error) {
+			return "", nil, test.ExpectedErr
+		},
+	)
+
+	err := flowconf.NewBuilder(source).Build(new(test.Config))
+
+	assert.ErrorIs(t, err, test.ExpectedErr)
+	assert.ErrorContains(t, err, "remote.json")
+}
+
+func TestBuilder_Build_canBeCalledMoreThanOnce(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(
+		test.FileSystem,
+		"data/config.toml",
+		"data/config-override-one.json",
+	)
+	assert.NoError(t, err)
+
+	inline := flowconf.NewSource("inline.json", flowconf.Json, io.NopCloser(strings.NewReader(`{"Pi": 3.1416}`)))
+	builder := flowconf.NewBuilder(append(sources, inline)...)
+
+	// /////////////////////// WHEN ///////////////////////
+	first, second := new(test.Config), new(test.Config)
+	errFirst := builder.Build(first)
+	errSecond := builder.Build(second)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, errFirst)
+	assert.NoError(t, errSecond)
+	assert.Equal(t, 42, second.MeaningOfLife)
+	assert.Equal(t, 3.1416, second.Pi)
+	assert.Equal(t, first, second)
+}
+
+func TestBuilder_Build_skipsMissingOptionalSources(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		missing []string
+		config  = new(test.Config)
+	)
+
+	sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(
+		test.FileSystem,
+		"data/config.toml",
+		"data/config-local.json?",
+	)
+	assert.NoError(t, err)
+
+	builder := flowconf.NewBuilder(sources...)
+	builder.SetMissingSourceHook(func(name string) {
+		missing = append(missing, name)
+	})
+
+	// /////////////////////// WHEN ///////////////////////
+	err = builder.Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, 42, config.MeaningOfLife)
+	assert.Equal(t, []string{"data/config-local.json"}, missing)
+}
+
+func TestBuilder_Build_failsOnInvalidOptionalSources(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.json")
+	assert.NoError(t, os.WriteFile(broken, []byte(`{"MeaningOfLife": `), 0o600))
+
+	_, err := flowconf.NewSourcesFromFilepaths(filepath.Join(dir, "required.json"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	sources, err := flowconf.NewSourcesFromFilepaths(broken + "?")
+	assert.NoError(t, err)
+
+	// /////////////////////// WHEN ///////////////////////
+	err = flowconf.NewBuilder(sources...).Build(new(test.Config))
+
+	// /////////////////////// THEN ///////////////////////
+	assert.ErrorContains(t, err, broken)
+	assert.NotErrorIs(t, err, flowconf.MissingSourceErr)
+}
+
+// lossyTime keeps the day only when encoded in JSON
+type lossyTime struct {
+	time.Time
+}
+
+func (t lossyTime) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + t.Format(time.DateOnly) + `"`), nil
+}
+
+type secretsInPlaceConfig struct {
+	DSN      string
+	Ignored  string `json:"-"`
+	Since    lossyTime
+	Hosts    []string
+	Tenants  map[string]struct{ Password string }
+	Extra    map[string]any
+	Nested   *struct{ Token string }
+	Any      any
+	internal string
+}
+
+func TestBuilder_Build_resolvesSecretsInPlace(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		since       = time.Date(1985, 10, 21, 1, 22, 0, 0, time.UTC)
+		managerMock = new(test.SecretManagerMock)
+		config      = &secretsInPlaceConfig{
+			DSN:      `postgres://app:@manager::password@db/"app"`,
+			Ignored:  "@manager::token",
+			Since:    lossyTime{since},
+			Hosts:    []string{"a", "@manager::token"},
+			Tenants:  map[string]struct{ Password string }{"acme": {Password: "@manager::password"}},
+			Extra:    map[string]any{"token": "@manager::token", "count": 1},
+			Nested:   &struct{ Token string }{Token: "@manager::token"},
+			Any:      []any{"@manager::token"},
+			internal: "@manager::token",
+		}
+	)
+
+	managerMock.On("Prefix").Return("manager")
+	managerMock.On("Secret", mock.Anything, "password").Return("p\"ass\nword", nil)
+	managerMock.On("Secret", mock.Anything, "token").Return("token", nil)
+
+	builder := flowconf.NewBuilder()
+	builder.SetSecretManagers(managerMock)
+
+	// /////////////////////// WHEN ///////////////////////
+	err := builder.Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, &secretsInPlaceConfig{
+		DSN:      "postgres://app:p\"ass\nword@db/\"app\"",
+		Ignored:  "token",
+		Since:    lossyTime{since},
+		Hosts:    []string{"a", "token"},
+		Tenants:  map[string]struct{ Password string }{"acme": {Password: "p\"ass\nword"}},
+		Extra:    map[string]any{"token": "token", "count": 1},
+		Nested:   &struct{ Token string }{Token: "token"},
+		Any:      []any{"token"},
+		internal: "@manager::token",
+	}, config)
+}
+
+func TestBuilder_Build_failsOnAnUnknownSecretManager(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	managerMock := new(test.SecretManagerMock)
+	managerMock.On("Prefix").Return("manager")
+
+	builder := flowconf.NewBuilder()
+	builder.SetSecretManagers(managerMock)
+
+	// /////////////////////// WHEN ///////////////////////
+	err := builder.Build(&secretsInPlaceConfig{DSN: "@other::password"})
+
+	// /////////////////////// THEN ///////////////////////
+	assert.EqualError(t, err, "manager not implemented for prefix: other")
+}
+
+func TestBuilder_BuildCtx_keepsTheReferencesWhenCancelled(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	managerMock := new(test.SecretManagerMock)
+	managerMock.On("Prefix").Return("manager")
+	managerMock.On("Secret", mock.Anything, "password").Return("password", nil)
+
+	builder := flowconf.NewBuilder()
+	builder.SetSecretManagers(managerMock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	config := &secretsInPlaceConfig{DSN: "@manager::password", Hosts: []string{"@manager::password"}}
+
+	// /////////////////////// WHEN ///////////////////////
+	err := builder.BuildCtx(ctx, config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "@manager::password", config.DSN)
+	assert.Equal(t, []string{"@manager::password"}, config.Hosts)
+}
@@ -0,0 +1,95 @@
+package flowconf
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// applyDefaults sets the fields having a `default` tag to the value of the tag,
+// the fields that are not at their zero value are kept. The slices are split
+// like the environment variables, on commas or on the `separator` tag.
+//
+// A nil pointer to a struct is allocated if the struct has defaults, unless the
+// struct type is already on the path, seen, so a recursive type is not
+// allocated without end. The paths of the fields set are returned.
+func applyDefaults(v reflect.Value, prefix string, seen map[reflect.Type]bool) ([]string, error) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct || isLeafType(v.Type()) {
+		return nil, nil
+	}
+
+	t := v.Type()
+	seen[t] = true
+	defer delete(seen, t)
+
+	var applied []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+		path := prefix + field.Name
+
+		if value, ok := field.Tag.Lookup("default"); ok {
+			if !fv.IsZero() {
+				continue
+			}
+			if err := setFromString(fv, value, separatorOf(field)); err != nil {
+				return nil, fmt.Errorf("invalid default value for %s, %w", path, err)
+			}
+			applied = append(applied, path)
+			continue
+		}
+
+		ft := indirectType(field.Type)
+		if ft.Kind() != reflect.Struct || isLeafType(ft) {
+			continue
+		}
+
+		if fv.Kind() == reflect.Pointer && fv.IsNil() && !seen[ft] && hasDefaults(ft, map[reflect.Type]bool{}) {
+			fv.Set(reflect.New(ft))
+		}
+
+		nested, err := applyDefaults(fv, path+".", seen)
+		if err != nil {
+			return nil, err
+		}
+		applied = append(applied, nested...)
+	}
+
+	return applied, nil
+}
+
+// hasDefaults reports if a field of the struct type t, or of a nested struct,
+// has a `default` tag
+func hasDefaults(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if _, ok := field.Tag.Lookup("default"); ok {
+			return true
+		}
+
+		ft := indirectType(field.Type)
+		if ft.Kind() == reflect.Struct && !isLeafType(ft) && hasDefaults(ft, seen) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package flowconf_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/stretchr/testify/assert"
+)
+
+type defaultsConfig struct {
+	Name     string        `default:"app"`
+	Port     int           `default:"8080"`
+	Debug    bool          `default:"true"`
+	Timeout  time.Duration `default:"5s"`
+	Origins  []string      `default:"a.com,b.com"`
+	Ports    []int         `default:"1;2" separator:";"`
+	Ratio    *float64      `default:"0.5"`
+	Empty    string
+	Database struct {
+		Host string `default:"localhost"`
+	}
+	Cache *struct {
+		Size int `default:"64"`
+	}
+	Optional *struct {
+		Size int
+	}
+}
+
+func TestBuilder_Build_defaults(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	source := flowconf.NewSource("config.json", flowconf.Json, io.NopCloser(strings.NewReader(`{"Port": 9090, "Origins": ["c.com"]}`)))
+
+	// /////////////////////// WHEN ///////////////////////
+	var config defaultsConfig
+	err := flowconf.NewBuilder(source).Build(&config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, 9090, config.Port)
+	assert.True(t, config.Debug)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, []string{"c.com"}, config.Origins)
+	assert.Equal(t, []int{1, 2}, config.Ports)
+	if assert.NotNil(t, config.Ratio) {
+		assert.Equal(t, 0.5, *config.Ratio)
+	}
+	assert.Empty(t, config.Empty)
+	assert.Equal(t, "localhost", config.Database.Host)
+	if assert.NotNil(t, config.Cache) {
+		assert.Equal(t, 64, config.Cache.Size)
+	}
+	assert.Nil(t, config.Optional)
+}
+
+func TestBuilder_Build_defaultsKeepTheValuesAlreadySet(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	config := defaultsConfig{Name: "set in code"}
+
+	// /////////////////////// WHEN ///////////////////////
+	err := flowconf.NewBuilder().Build(&config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, "set in code", config.Name)
+	assert.Equal(t, 8080, config.Port)
+}
+
+func TestBuilder_Build_invalidDefault(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var config struct {
+		Nested struct {
+			Port int `default:"http"`
+		}
+	}
+
+	// /////////////////////// WHEN ///////////////////////
+	err := flowconf.NewBuilder().Build(&config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.ErrorContains(t, err, "invalid default value for Nested.Port")
+}
+
+type defaultsNode struct {
+	Value int `default:"1"`
+	Next  *defaultsNode
+	Leaf  *struct {
+		Value int `default:"2"`
+	}
+}
+
+func TestBuilder_Build_defaultsOfARecursiveType(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	config := &defaultsNode{Next: &defaultsNode{}}
+
+	// /////////////////////// WHEN ///////////////////////
+	err := flowconf.NewBuilder().Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, 1, config.Value)
+	assert.Equal(t, 2, config.Leaf.Value)
+	assert.Equal(t, 1, config.Next.Value)
+	assert.Equal(t, 2, config.Next.Leaf.Value)
+	assert.Nil(t, config.Next.Next)
+}
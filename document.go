@@ -0,0 +1,163 @@
+package flowconf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// includeKey is the top level key listing the files included by a file source
+const includeKey = "include"
+
+// document is the content of a source, read once per build
+type document struct {
+	// chain is the name of the source, preceded by the names of the sources
+	// including it
+	chain   []string
+	format  Format
+	content []byte
+	// fromFile is true for the documents of a file source, which can include
+	// other files
+	fromFile bool
+}
+
+func (doc *document) name() string {
+	return strings.Join(doc.chain, " -> ")
+}
+
+// decode decodes the content of the document into config
+func (doc *document) decode(config any) error {
+	decode, err := decoderFor(doc.format)
+	if err != nil {
+		return err
+	}
+
+	return decode(config, bytes.NewReader(doc.content))
+}
+
+// readDocument loads the source and reads its whole content
+func readDocument(ctx context.Context, source Source, chain []string) (*document, error) {
+	chain = append(chain[:len(chain):len(chain)], source.Name())
+	name := strings.Join(chain, " -> ")
+
+	format, reader, err := source.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load source: %s, %w", name, err)
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		_ = reader.Close()
+		return nil, fmt.Errorf("failed to read source: %s, %w", name, err)
+	}
+
+	err = reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close source: %s, %s", name, err)
+	}
+
+	return &document{chain: chain, format: format, content: content}, nil
+}
+
+// loadDocuments reads the source and, for a file source, the files it includes.
+//
+// A file includes other files with a top level `include` list, the paths are
+// relative to the including file and opened with the same Opener. The included
+// files are returned first, in the order of the list, so the including file
+// overrides them.
+func loadDocuments(ctx context.Context, source Source) ([]*document, error) {
+	return loadDocumentsWithChain(ctx, source, nil)
+}
+
+func loadDocumentsWithChain(ctx context.Context, source Source, chain []string) ([]*document, error) {
+	doc, err := readDocument(ctx, source, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	file, ok := source.(*fileSource)
+	if !ok {
+		return []*document{doc}, nil
+	}
+	doc.fromFile = true
+
+	var docs []*document
+	for _, include := range doc.includes() {
+		included := newFileSource(file.opener, includePath(file.opener, file.name, include), nil)
+		for _, name := range doc.chain {
+			if name == included.name {
+				return nil, fmt.Errorf("include cycle: %s -> %s", doc.name(), included.name)
+			}
+		}
+
+		includedDocs, err := loadDocumentsWithChain(ctx, included, doc.chain)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, includedDocs...)
+	}
+
+	return append(docs, doc), nil
+}
+
+// isInclude reports if the key is the include list of a file
+func (doc *document) isInclude(key docKey) bool {
+	return doc.fromFile && key.key == includeKey
+}
+
+// hasTree reports if the document can be decoded into a map to be inspected,
+// the decoder of a format swapped with RegisterFormat may only decode into the
+// configuration
+func (doc *document) hasTree() bool {
+	return treeDecoderFor(doc.format) != nil
+}
+
+// tree decodes the document into a map, it returns nil if the format is not a
+// tree format
+func (doc *document) tree() (map[string]any, error) {
+	decode := treeDecoderFor(doc.format)
+	if decode == nil {
+		return nil, nil
+	}
+
+	var tree map[string]any
+	err := decode(&tree, bytes.NewReader(doc.content))
+	return tree, err
+}
+
+// includes returns the list of the include key, a document that cannot be
+// decoded has no includes, the error is reported when it is decoded into the
+// configuration
+func (doc *document) includes() []string {
+	tree, _ := doc.tree()
+
+	list, _ := tree[includeKey].([]any)
+	var includes []string
+	for _, item := range list {
+		if include, ok := item.(string); ok {
+			includes = append(includes, include)
+		}
+	}
+
+	return includes
+}
+
+// includePath resolves the include relatively to the including file, the files
+// of a fs.FS always use forward slashes
+func includePath(opener Opener, including string, include string) string {
+	if _, ok := opener.(fsOpener); ok {
+		if path.IsAbs(include) {
+			return include
+		}
+		return path.Join(path.Dir(including), include)
+	}
+
+	if filepath.IsAbs(include) {
+		return include
+	}
+	return filepath.Join(filepath.Dir(including), include)
+}
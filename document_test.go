@@ -0,0 +1,89 @@
+package flowconf_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_Build_includesFiles(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	fsys := fstest.MapFS{
+		"app.toml": {Data: []byte(`
+include = [ "db/db.toml", "cache.json" ]
+Secret = "from app.toml"
+`)},
+		"db/db.toml": {Data: []byte(`
+include = [ "../shared.yaml" ]
+Pi = 3.14
+Secret = "from db.toml"
+`)},
+		"shared.yaml": {Data: []byte("meaningoflife: 42\npi: 3\ncats: [ James, Bond ]")},
+		"cache.json":  {Data: []byte(`{"Cats": ["Bob", "Morane"], "Secret": "from cache.json"}`)},
+	}
+
+	sources, err := flowconf.NewSourcesFromFS(fsys, "app.toml")
+	assert.NoError(t, err)
+
+	config := new(test.Config)
+
+	// /////////////////////// WHEN ///////////////////////
+	err = flowconf.NewBuilder(sources...).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, &test.Config{
+		MeaningOfLife: 42,
+		Cats:          []string{"Bob", "Morane"},
+		Pi:            3.14,
+		Secret:        "from app.toml",
+	}, config)
+}
+
+func TestBuilder_Build_includeErrorsNameTheChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{
+			name: "cycle",
+			fsys: fstest.MapFS{
+				"app.toml":    {Data: []byte(`include = [ "db.json" ]`)},
+				"db.json":     {Data: []byte(`{"include": ["shared.yaml"]}`)},
+				"shared.yaml": {Data: []byte(`include: [ app.toml ]`)},
+			},
+			wantErr: "include cycle: app.toml -> db.json -> shared.yaml -> app.toml",
+		},
+		{
+			name: "missing file",
+			fsys: fstest.MapFS{
+				"app.toml": {Data: []byte(`include = [ "db.json" ]`)},
+				"db.json":  {Data: []byte(`{"include": ["missing.toml"]}`)},
+			},
+			wantErr: "failed to load source: app.toml -> db.json -> missing.toml",
+		},
+		{
+			name: "invalid file",
+			fsys: fstest.MapFS{
+				"app.toml": {Data: []byte(`include = [ "db.json" ]`)},
+				"db.json":  {Data: []byte(`{"MeaningOfLife": "not a number"}`)},
+			},
+			wantErr: "failed to process source: app.toml -> db.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				sources, err := flowconf.NewSourcesFromFS(tt.fsys, "app.toml")
+				assert.NoError(t, err)
+
+				err = flowconf.NewBuilder(sources...).Build(new(test.Config))
+				assert.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+}
@@ -0,0 +1,266 @@
+package flowconf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Dotenv is the format of the .env files. The keys are mapped onto the
+// configuration like the environment variables of NewSourceFromEnv
+const Dotenv Format = "dotenv"
+
+func init() {
+	RegisterFormat(Dotenv, []string{".env"}, parseDotenv)
+}
+
+func parseDotenv(config any, r io.Reader) error {
+	entries, err := readDotenv(r)
+	if err != nil {
+		return err
+	}
+
+	return decodeEntries(config, entries, "_")
+}
+
+// readDotenv parses the KEY=VALUE lines of a .env file.
+//
+//   - lines starting with # are comments, so is the end of an unquoted value after " #"
+//   - the optional `export` prefix is ignored
+//   - single quoted values are taken as is and can span multiple lines
+//   - double quoted values can span multiple lines, support the \n \r \t \" \\ \$ escapes
+//     and are expanded
+//   - unquoted values are trimmed and expanded
+//
+// Expansion replaces ${VAR}, ${VAR:-default} and $VAR with the value of a key
+// defined above in the file, or else of the environment variable.
+func readDotenv(r io.Reader) ([]entry, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dotenvParser{
+		src:  strings.ReplaceAll(string(b), "\r\n", "\n"),
+		line: 1,
+		vars: map[string]string{},
+	}
+
+	return p.parse()
+}
+
+type dotenvParser struct {
+	src     string
+	pos     int
+	line    int
+	vars    map[string]string
+	entries []entry
+}
+
+func (p *dotenvParser) parse() ([]entry, error) {
+	for {
+		p.skip(" \t\n")
+		if p.eof() {
+			return p.entries, nil
+		}
+
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		if strings.HasPrefix(p.src[p.pos:], "export") &&
+			p.pos+6 < len(p.src) && strings.ContainsRune(" \t", rune(p.src[p.pos+6])) {
+			p.pos += 6
+			p.skip(" \t")
+		}
+
+		key := p.readKey()
+		if key == "" {
+			return nil, p.errorf("invalid key")
+		}
+
+		p.skip(" \t")
+		if p.eof() || p.peek() != '=' {
+			return nil, p.errorf("missing = after %s", key)
+		}
+		p.pos++
+		p.skip(" \t")
+
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+
+		p.vars[key] = value
+		p.entries = append(p.entries, entry{key: key, value: value})
+	}
+}
+
+func (p *dotenvParser) readKey() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		isLetter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && !(isDigit && p.pos > start) && c != '.' {
+			break
+		}
+		p.pos++
+	}
+
+	return p.src[start:p.pos]
+}
+
+func (p *dotenvParser) readValue() (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+
+	var value string
+	switch quote := p.peek(); quote {
+	case '\'', '"':
+		start := p.pos
+		end, ok := p.findClosingQuote(quote)
+		if !ok {
+			return "", p.errorf("unterminated quoted value")
+		}
+		value = p.src[start+1 : end]
+		p.line += strings.Count(value, "\n")
+		p.pos = end + 1
+
+		if quote == '"' {
+			value = p.expand(value, true)
+		}
+
+		// only a comment can follow a quoted value
+		p.skip(" \t")
+		if !p.eof() && p.peek() != '\n' && p.peek() != '#' {
+			return "", p.errorf("unexpected character after quoted value")
+		}
+		p.skipLine()
+	default:
+		end := strings.IndexByte(p.src[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		value = p.src[p.pos : p.pos+end]
+		p.pos += end
+
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		value = p.expand(strings.TrimSpace(value), false)
+	}
+
+	return value, nil
+}
+
+// findClosingQuote returns the position of the quote ending the value starting at p.pos
+func (p *dotenvParser) findClosingQuote(quote byte) (int, bool) {
+	for i := p.pos + 1; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// expand replaces the variables of the value, and the escape sequences if escapes is true
+func (p *dotenvParser) expand(value string, escapes bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\\' && escapes && i+1 < len(value):
+			i++
+			switch value[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\', '$':
+				sb.WriteByte(value[i])
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(value[i])
+			}
+		case c == '$' && i+1 < len(value) && value[i+1] == '{':
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				sb.WriteString(value[i:])
+				return sb.String()
+			}
+			name, fallback, hasFallback := strings.Cut(value[i+2:i+end], ":-")
+			v, ok := p.lookup(name)
+			if !ok || (hasFallback && v == "") {
+				v = fallback
+			}
+			sb.WriteString(v)
+			i += end
+		case c == '$':
+			end := i + 1
+			for end < len(value) && (value[end] == '_' ||
+				value[end] >= 'a' && value[end] <= 'z' ||
+				value[end] >= 'A' && value[end] <= 'Z' ||
+				value[end] >= '0' && value[end] <= '9') {
+				end++
+			}
+			if end == i+1 {
+				sb.WriteByte(c)
+				continue
+			}
+			v, _ := p.lookup(value[i+1 : end])
+			sb.WriteString(v)
+			i = end - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
+
+func (p *dotenvParser) lookup(name string) (string, bool) {
+	if v, ok := p.vars[name]; ok {
+		return v, true
+	}
+
+	return os.LookupEnv(name)
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) skip(chars string) {
+	for !p.eof() && strings.IndexByte(chars, p.peek()) >= 0 {
+		if p.peek() == '\n' {
+			p.line++
+		}
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
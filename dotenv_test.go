@@ -0,0 +1,79 @@
+package flowconf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readDotenv(t *testing.T) {
+	t.Setenv("FLOWCONF_TEST_HOME", "/home/flow")
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []entry
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "unquoted values are trimmed and comments are ignored",
+			input:   "# comment\nA=1\n  B = two words  # trailing comment\nC=\n",
+			want:    []entry{{"A", "1"}, {"B", "two words"}, {"C", ""}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "export prefix is ignored",
+			input:   "export A=1\nexporter=2",
+			want:    []entry{{"A", "1"}, {"exporter", "2"}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "single quoted values are literal",
+			input:   `A='$HOME \n # not a comment'`,
+			want:    []entry{{"A", `$HOME \n # not a comment`}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "double quoted values are escaped and expanded",
+			input:   `A="say \"hi\"\n\$${FLOWCONF_TEST_HOME}" # comment`,
+			want:    []entry{{"A", "say \"hi\"\n$/home/flow"}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "multi-line values",
+			input:   "A=\"first\nsecond\"\nB='third\nfourth'\nC=5",
+			want:    []entry{{"A", "first\nsecond"}, {"B", "third\nfourth"}, {"C", "5"}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "expansion from previous keys, the environment and defaults",
+			input:   "A=one\nB=$A-${FLOWCONF_TEST_HOME}\nC=${FLOWCONF_TEST_MISSING:-fallback}\nD=${FLOWCONF_TEST_MISSING}",
+			want:    []entry{{"A", "one"}, {"B", "one-/home/flow"}, {"C", "fallback"}, {"D", ""}},
+			wantErr: assert.NoError,
+		},
+		{
+			name:  "unterminated quote",
+			input: "A=1\nB=\"open",
+			want:  nil,
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorContains(t, err, "line 2")
+			},
+		},
+		{
+			name:    "missing equal sign",
+			input:   "A 1",
+			want:    nil,
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := readDotenv(strings.NewReader(tt.input))
+				tt.wantErr(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
@@ -0,0 +1,129 @@
+package flowconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// RedactionMarker replaces the value of a secret in a dump
+const RedactionMarker = "[REDACTED]"
+
+// Dump encodes the configuration in the format, Json, Toml or Yaml, to log it.
+// The keys are named with the tags of the format.
+//
+// The fields whose origin in the provenance of the build of the configuration
+// lists a secret, the Secret values and the fields tagged `secret:"true"` are
+// replaced by the RedactionMarker, unless they are empty. A slice holding a
+// secret is redacted as a whole. The nil pointers, maps and slices are left out.
+func Dump(config any, format Format, provenance map[string]Origin) ([]byte, error) {
+	if !treeFormats[format] {
+		return nil, fmt.Errorf("no dump for the format: %s", format)
+	}
+
+	redacted := map[string]bool{}
+	for path, origin := range provenance {
+		if len(origin.Secrets) > 0 {
+			redacted[path] = true
+		}
+	}
+
+	tree, _ := dumpTree(reflect.ValueOf(config), format, "", redacted)
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case Json:
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(tree)
+	case Toml:
+		err = toml.NewEncoder(&buf).Encode(tree)
+	case Yaml:
+		err = yaml.NewEncoder(&buf).Encode(tree)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump the configuration, %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// dumpTree converts the value to maps and slices to encode, the structs are
+// keyed by the names of the format. It returns false for a nil value.
+func dumpTree(v reflect.Value, format Format, path string, redacted map[string]bool) (any, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	if redacted[path] && !v.IsZero() {
+		return RedactionMarker, true
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if isLeafType(v.Type()) {
+			break
+		}
+
+		tree := map[string]any{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := keyName(field, format)
+			if !ok {
+				continue
+			}
+
+			fieldPath := joinPath(path, field.Name)
+			if field.Tag.Get("secret") == "true" && !v.Field(i).IsZero() {
+				tree[name] = RedactionMarker
+				continue
+			}
+
+			if value, ok := dumpTree(v.Field(i), format, fieldPath, redacted); ok {
+				tree[name] = value
+			}
+		}
+		return tree, true
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, false
+		}
+
+		tree := map[string]any{}
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key())
+			if value, ok := dumpTree(iter.Value(), format, joinPath(path, key), redacted); ok {
+				tree[key] = value
+			}
+		}
+		return tree, true
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, false
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		fallthrough
+	case reflect.Array:
+		list := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if value, ok := dumpTree(v.Index(i), format, path, redacted); ok {
+				list = append(list, value)
+			}
+		}
+		return list, true
+	}
+
+	return v.Interface(), true
+}
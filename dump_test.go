@@ -0,0 +1,188 @@
+package flowconf_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type dumpConfig struct {
+	Name     string        `json:"name" toml:"name" yaml:"name"`
+	Timeout  time.Duration `json:"timeout" toml:"timeout" yaml:"timeout"`
+	APIKey   string        `json:"api_key" toml:"api_key" yaml:"api_key" secret:"true"`
+	Token    string        `json:"token" toml:"token" yaml:"token" secret:"true"`
+	Database struct {
+		Host     string `json:"host" toml:"host" yaml:"host"`
+		Password string `json:"password" toml:"password" yaml:"password"`
+	} `json:"database" toml:"database" yaml:"database"`
+	Cache   *struct{ Size int } `json:"cache" toml:"cache" yaml:"cache"`
+	Labels  map[string]string   `json:"labels" toml:"labels" yaml:"labels"`
+	Hidden  string              `json:"-" toml:"-" yaml:"-"`
+	Servers []struct {
+		Host     string `json:"host" toml:"host" yaml:"host"`
+		Password string `json:"password" toml:"password" yaml:"password"`
+	} `json:"servers" toml:"servers" yaml:"servers"`
+}
+
+func TestDump(t *testing.T) {
+	tests := []struct {
+		name   string
+		format flowconf.Format
+		want   string
+	}{
+		{
+			name:   "json",
+			format: flowconf.Json,
+			want: `{
+  "api_key": "[REDACTED]",
+  "database": {
+    "host": "db",
+    "password": "[REDACTED]"
+  },
+  "labels": {
+    "team": "core"
+  },
+  "name": "app",
+  "timeout": 5000000000,
+  "token": ""
+}
+`,
+		},
+		{
+			name:   "toml",
+			format: flowconf.Toml,
+			want: `api_key = "[REDACTED]"
+name = "app"
+timeout = "5s"
+token = ""
+
+[database]
+  host = "db"
+  password = "[REDACTED]"
+
+[labels]
+  team = "core"
+`,
+		},
+		{
+			name:   "yaml",
+			format: flowconf.Yaml,
+			want: `api_key: '[REDACTED]'
+database:
+    host: db
+    password: '[REDACTED]'
+labels:
+    team: core
+name: app
+timeout: 5s
+token: ""
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// /////////////////////// GIVEN ///////////////////////
+			var (
+				source = flowconf.NewSource("config.json", flowconf.Json, io.NopCloser(strings.NewReader(`{
+					"name": "app",
+					"timeout": 5000000000,
+					"api_key": "plain text key",
+					"database": {"host": "db", "password": "@manager::password"},
+					"labels": {"team": "core"}
+				}`)))
+				managerMock = new(test.SecretManagerMock)
+				config      = new(dumpConfig)
+			)
+
+			managerMock.On("Prefix").Return("manager")
+			managerMock.On("Secret", mock.Anything, "password").Return("very secret", nil)
+
+			builder := flowconf.NewBuilder(source)
+			builder.SetSecretManagers(managerMock)
+			assert.NoError(t, builder.Build(config))
+
+			// /////////////////////// WHEN ///////////////////////
+			got, err := flowconf.Dump(config, tt.format, builder.Provenance())
+
+			// /////////////////////// THEN ///////////////////////
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+			assert.NotContains(t, string(got), "very secret")
+			assert.NotContains(t, string(got), "plain text key")
+		})
+	}
+}
+
+func TestDump_secretsOfTheSliceElements(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		source = flowconf.NewSource("config.json", flowconf.Json, io.NopCloser(strings.NewReader(`{
+			"servers": [{"host": "a", "password": "plain"}, {"host": "b", "password": "@manager::server"}]
+		}`)))
+		managerMock = new(test.SecretManagerMock)
+		config      = new(dumpConfig)
+	)
+
+	managerMock.On("Prefix").Return("manager")
+	managerMock.On("Secret", mock.Anything, "server").Return("very secret", nil)
+
+	builder := flowconf.NewBuilder(source)
+	builder.SetSecretManagers(managerMock)
+	assert.NoError(t, builder.Build(config))
+
+	// /////////////////////// WHEN ///////////////////////
+	got, err := flowconf.Dump(config, flowconf.Yaml, builder.Provenance())
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, `api_key: ""
+database:
+    host: ""
+    password: ""
+name: ""
+servers: '[REDACTED]'
+timeout: 0s
+token: ""
+`, string(got))
+}
+
+func TestDump_copyOfTheConfiguration(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	source := flowconf.NewSource("config.json", flowconf.Json, io.NopCloser(strings.NewReader(
+		`{"database": {"password": "@manager::password"}}`,
+	)))
+
+	managerMock := new(test.SecretManagerMock)
+	managerMock.On("Prefix").Return("manager")
+	managerMock.On("Secret", mock.Anything, "password").Return("very secret", nil)
+
+	builder := flowconf.NewBuilder(source)
+	builder.SetSecretManagers(managerMock)
+
+	config := new(dumpConfig)
+	assert.NoError(t, builder.Build(config))
+	copied := *config
+
+	// /////////////////////// WHEN ///////////////////////
+	got, err := flowconf.Dump(copied, flowconf.Json, builder.Provenance())
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Contains(t, string(got), `"password": "[REDACTED]"`)
+	assert.NotContains(t, string(got), "very secret")
+}
+
+func TestDump_unsupportedFormat(t *testing.T) {
+	// /////////////////////// WHEN ///////////////////////
+	_, err := flowconf.Dump(new(dumpConfig), flowconf.Dotenv, nil)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.EqualError(t, err, "no dump for the format: dotenv")
+}
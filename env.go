@@ -0,0 +1,116 @@
+package flowconf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Environ is the format of the environment sources, a list of KEY=VALUE
+// entries separated by a NUL byte, like /proc/<pid>/environ
+const Environ Format = "environ"
+
+func init() {
+	RegisterFormat(Environ, nil, parseEnviron)
+}
+
+// NewSourceFromEnv creates a source from the environment variables starting with
+// the prefix. The prefix is removed and the rest of the name is mapped onto the
+// configuration, every underscore possibly going one struct deeper.
+//
+// With the prefix APP, the variable APP_DATABASE_HOST sets the field
+// Database.Host, it also matches a field named DatabaseHost or a field with a
+// `json`, `toml` or `yaml` tag named database_host. The value is converted to
+// the type of the field, slices are split on commas (or the `separator` tag).
+//
+// The environment is read every time the source is loaded.
+func NewSourceFromEnv(prefix string) Source {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+
+	return NewLazySource(
+		fmt.Sprintf("env(%s*)", prefix),
+		func(_ context.Context) (Format, io.ReadCloser, error) {
+			var buf bytes.Buffer
+			for _, env := range os.Environ() {
+				if !strings.HasPrefix(env, prefix) {
+					continue
+				}
+				buf.WriteString(strings.TrimPrefix(env, prefix))
+				buf.WriteByte(0)
+			}
+
+			return Environ, io.NopCloser(&buf), nil
+		},
+	)
+}
+
+func parseEnviron(config any, r io.Reader) error {
+	entries, err := readEnviron(r)
+	if err != nil {
+		return err
+	}
+
+	return decodeEntries(config, entries, "_")
+}
+
+// entry is a KEY=VALUE entry of a flat format
+type entry struct {
+	key   string
+	value string
+}
+
+// flatFormat is a format made of KEY=VALUE entries, the keys are split on sep
+// to find the field they set
+type flatFormat struct {
+	read func(r io.Reader) ([]entry, error)
+	sep  string
+}
+
+var flatFormats = map[Format]flatFormat{
+	Environ:     {read: readEnviron, sep: "_"},
+	Dotenv:      {read: readDotenv, sep: "_"},
+	flagsFormat: {read: readEnviron, sep: "."},
+}
+
+// readEnviron reads the entries separated by a NUL byte
+func readEnviron(r io.Reader) ([]entry, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for _, line := range strings.Split(string(b), "\x00") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || key == "" {
+			continue
+		}
+		entries = append(entries, entry{key: key, value: value})
+	}
+
+	return entries, nil
+}
+
+// decodeEntries sets the values of the entries on the fields they lead to,
+// the entries matching no field and the tombstones are ignored
+func decodeEntries(config any, entries []entry, sep string) error {
+	rv := reflect.ValueOf(config)
+	for _, entry := range entries {
+		if entry.value == UnsetMarker {
+			continue
+		}
+
+		_, err := setFromPath(rv, strings.Split(entry.key, sep), entry.value)
+		if err != nil {
+			return fmt.Errorf("failed to set %s, %w", entry.key, err)
+		}
+	}
+
+	return nil
+}
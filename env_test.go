@@ -0,0 +1,84 @@
+package flowconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type envConfig struct {
+	Name     string
+	Debug    bool
+	Database struct {
+		Host    string
+		Port    int
+		Timeout time.Duration
+	}
+	Cache *struct {
+		Size uint
+	}
+	AllowedOrigins []string  `json:"allowed_origins"`
+	Ratios         []float64 `separator:";"`
+}
+
+func TestNewSourceFromEnv_mapsVariablesOntoNestedFields(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	t.Setenv("FLOWCONF_TEST_NAME", "from env")
+	t.Setenv("FLOWCONF_TEST_DEBUG", "true")
+	t.Setenv("FLOWCONF_TEST_DATABASE_HOST", "db.internal")
+	t.Setenv("FLOWCONF_TEST_DATABASE_PORT", "5432")
+	t.Setenv("FLOWCONF_TEST_DATABASE_TIMEOUT", "1m30s")
+	t.Setenv("FLOWCONF_TEST_CACHE_SIZE", "128")
+	t.Setenv("FLOWCONF_TEST_ALLOWED_ORIGINS", "a.com, b.com")
+	t.Setenv("FLOWCONF_TEST_RATIOS", "0.5;1.5")
+	t.Setenv("FLOWCONF_TEST_NOT_A_FIELD", "ignored")
+
+	config := new(envConfig)
+
+	// /////////////////////// WHEN ///////////////////////
+	err := flowconf.NewBuilder(flowconf.NewSourceFromEnv("FLOWCONF_TEST")).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, "from env", config.Name)
+	assert.True(t, config.Debug)
+	assert.Equal(t, "db.internal", config.Database.Host)
+	assert.Equal(t, 5432, config.Database.Port)
+	assert.Equal(t, 90*time.Second, config.Database.Timeout)
+	if assert.NotNil(t, config.Cache) {
+		assert.Equal(t, uint(128), config.Cache.Size)
+	}
+	assert.Equal(t, []string{"a.com", "b.com"}, config.AllowedOrigins)
+	assert.Equal(t, []float64{0.5, 1.5}, config.Ratios)
+}
+
+func TestNewSourceFromEnv_overridesPreviousSources(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	t.Setenv("FLOWCONF_TEST_MEANING_OF_LIFE", "43")
+	t.Setenv("FLOWCONF_TEST_TAGVALUE", "from env")
+
+	sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(test.FileSystem, "data/config.toml")
+	assert.NoError(t, err)
+
+	config := new(test.Config)
+
+	// /////////////////////// WHEN ///////////////////////
+	err = flowconf.NewBuilder(append(sources, flowconf.NewSourceFromEnv("FLOWCONF_TEST"))...).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, 43, config.MeaningOfLife)
+	assert.Equal(t, "from env", config.Tag)
+	assert.Equal(t, []string{"James", "Bond"}, config.Cats)
+}
+
+func TestNewSourceFromEnv_invalidValueFailsTheBuild(t *testing.T) {
+	t.Setenv("FLOWCONF_TEST_DATABASE_PORT", "not a number")
+
+	err := flowconf.NewBuilder(flowconf.NewSourceFromEnv("FLOWCONF_TEST")).Build(new(envConfig))
+
+	assert.ErrorContains(t, err, "DATABASE_PORT")
+}
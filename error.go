@@ -1,8 +1,114 @@
 package flowconf
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	NotAPtrErr = errors.New("config needs to be a pointer")
 	IsNilErr   = errors.New("config is nil")
+	// MissingSourceErr is returned by a Source that does not exist but is
+	// optional, the Builder skips it
+	MissingSourceErr = errors.New("optional source is missing")
 )
+
+// UnknownKey is a key of a source matching no field of the configuration
+type UnknownKey struct {
+	Source string
+	Key    string
+}
+
+// UnknownKeysError is returned by a strict Builder when the sources set keys
+// matching no field of the configuration
+type UnknownKeysError struct {
+	Keys []UnknownKey
+}
+
+func (err *UnknownKeysError) Error() string {
+	keys := make([]string, 0, len(err.Keys))
+	for _, key := range err.Keys {
+		keys = append(keys, fmt.Sprintf("%s in %s", key.Key, key.Source))
+	}
+
+	return "unknown keys: " + strings.Join(keys, ", ")
+}
+
+// MissingFieldsError is returned by the Builder when fields tagged
+// `required:"true"` are not set by any of the sources
+type MissingFieldsError struct {
+	// Fields are the paths of the missing fields, like "Database.Host"
+	Fields []string
+	// Sources are the names of the sources that were consulted
+	Sources []string
+}
+
+func (err *MissingFieldsError) Error() string {
+	return fmt.Sprintf(
+		"missing required fields: %s, sources consulted: %s",
+		strings.Join(err.Fields, ", "),
+		strings.Join(err.Sources, ", "),
+	)
+}
+
+// Violation is a value of the configuration breaking a rule of its `validate`
+// tag, or the error returned by the Validate method of a struct
+type Violation struct {
+	// Field is the path of the field, like "Database.Port", it is empty for
+	// the Validate method of the configuration
+	Field string
+	// Rule is the name of the rule, like "max", or "Validate"
+	Rule    string
+	Message string
+}
+
+// ValidationError is returned by the Builder when the built configuration is
+// not valid
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (err *ValidationError) Error() string {
+	violations := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		if violation.Field == "" {
+			violations = append(violations, violation.Message)
+			continue
+		}
+		violations = append(violations, fmt.Sprintf("%s %s", violation.Field, violation.Message))
+	}
+
+	return "invalid configuration: " + strings.Join(violations, ", ")
+}
+
+// SchemaViolation is a rule of the JSON Schema given to the Builder that is
+// violated by a source, or by the merged configuration
+type SchemaViolation struct {
+	Source string
+	// Key is the path of the key, like "Database.Port", it is empty for the
+	// whole document
+	Key string
+	// Rule is the keyword of the schema, like "maximum"
+	Rule    string
+	Message string
+}
+
+// SchemaError is returned by the Builder when the sources do not match the
+// JSON Schema given to it
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+func (err *SchemaError) Error() string {
+	violations := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		location := violation.Source
+		if violation.Key != "" {
+			location = fmt.Sprintf("%s in %s", violation.Key, violation.Source)
+		}
+		violations = append(violations, fmt.Sprintf("%s violates %s: %s", location, violation.Rule, violation.Message))
+	}
+
+	return "schema violations: " + strings.Join(violations, ", ")
+}
@@ -31,6 +31,16 @@ func init() {
 }
 
 func main() {
+	conf := Configurations{}
+
+	// every configuration field can also be set with a flag,
+	// example: --environment=staging
+	flags, err := flowconf.BindFlags(flag.CommandLine, &conf)
+	if err != nil {
+		// handle error
+		panic(err)
+	}
+
 	// the configuration files are passed to the application via
 	// configuration flags
 	// example:
@@ -44,14 +54,13 @@ func main() {
 		panic(err)
 	}
 
-	// CREATE the Builder with static sources
-	builder := flowconf.NewBuilder(sources...)
+	// CREATE the Builder with static sources, the flags are last so they have the final say
+	builder := flowconf.NewBuilder(append(sources, flags.Source())...)
 	// add a manager that will fetch values from an external provider like GCP Secret Manager,
 	// here we fake it
 	builder.SetSecretManagers(fakeManager())
 
 	// BUILD the configurations
-	conf := Configurations{}
 	err = builder.Build(&conf)
 	if err != nil {
 		// handle error
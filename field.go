@@ -0,0 +1,145 @@
+package flowconf
+
+import (
+	"reflect"
+	"strings"
+)
+
+// resolvePath finds the field the segments of a flat key lead to, like the
+// parts of an environment variable name, and returns the path of its Go field
+// names. Consecutive segments can match a single field, the longest match wins.
+func resolvePath(t reflect.Type, segments []string) ([]string, bool) {
+	if len(segments) == 0 {
+		return nil, true
+	}
+
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct || isLeafType(t) {
+		return nil, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && !hasNameTag(field) {
+			if path, ok := resolvePath(field.Type, segments); ok {
+				return append([]string{field.Name}, path...), true
+			}
+			continue
+		}
+
+		for n := len(segments); n > 0; n-- {
+			if !matchesFieldName(field, strings.Join(segments[:n], "_")) {
+				continue
+			}
+
+			if path, ok := resolvePath(field.Type, segments[n:]); ok {
+				return append([]string{field.Name}, path...), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// fieldByPath returns the field at the path of Go field names, the nil
+// pointers on the way are allocated if alloc is true
+func fieldByPath(v reflect.Value, path []string, alloc bool) (reflect.Value, reflect.StructField, bool) {
+	var field reflect.StructField
+	for _, name := range path {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, field, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+
+		var ok bool
+		if field, ok = v.Type().FieldByName(name); !ok {
+			return reflect.Value{}, field, false
+		}
+		v = v.FieldByIndex(field.Index)
+	}
+
+	return v, field, true
+}
+
+// setFromPath sets the value on the field the segments of a flat key lead to,
+// see resolvePath. It reports if a field matched.
+func setFromPath(v reflect.Value, segments []string, value string) (bool, error) {
+	path, ok := resolvePath(v.Type(), segments)
+	if !ok {
+		return false, nil
+	}
+
+	field, structField, _ := fieldByPath(v, path, true)
+	return true, setFromString(field, value, separatorOf(structField))
+}
+
+// nameTags are the struct tags naming a field in the configuration files
+var nameTags = []string{"json", "toml", "yaml"}
+
+// tagName returns the name given to the field by the tag
+func tagName(field reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+func hasNameTag(field reflect.StructField) bool {
+	for _, tag := range nameTags {
+		if tagName(field, tag) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesFieldName compares the name to the field name and its tag names,
+// ignoring the case, the underscores and the dashes
+func matchesFieldName(field reflect.StructField, name string) bool {
+	name = normalizeName(name)
+	if name == normalizeName(field.Name) {
+		return true
+	}
+
+	for _, tag := range nameTags {
+		if tn := tagName(field, tag); tn != "" && name == normalizeName(tn) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+// flowconfOption returns the value of the option of the `flowconf` tag,
+// `flowconf:"merge=append"` has the option merge with the value append
+func flowconfOption(field reflect.StructField, option string) (string, bool) {
+	tag, ok := field.Tag.Lookup("flowconf")
+	if !ok {
+		return "", false
+	}
+
+	for _, opt := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		if name == option {
+			return value, true
+		}
+	}
+
+	return "", false
+}
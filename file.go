@@ -1,9 +1,13 @@
 package flowconf
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
+	"sync"
 )
 
 // Opener is an interface for opening files on the file system or embedded filesystem
@@ -18,9 +22,56 @@ func (opener osOpener) Open(filepath string) (io.ReadCloser, error) {
 	return opener(filepath)
 }
 
-// embeddedOpener is a wrapper to open embedded file
-type embeddedOpener func(name string) (fs.File, error)
+// fsOpener is a wrapper to open a file of a fs.FS, like an embedded file
+type fsOpener func(name string) (fs.File, error)
 
-func (opener embeddedOpener) Open(name string) (io.ReadCloser, error) {
+func (opener fsOpener) Open(name string) (io.ReadCloser, error) {
 	return opener(name)
 }
+
+// fileSource is a file opened with an Opener. The file opened by the loader is
+// used for the first load, it is opened again for the next ones so every build
+// reads the current content
+type fileSource struct {
+	name     string
+	format   Format
+	opener   Opener
+	optional bool
+
+	mu     sync.Mutex
+	opened io.ReadCloser
+}
+
+func newFileSource(opener Opener, filepath string, opened io.ReadCloser) *fileSource {
+	return &fileSource{
+		name:   filepath,
+		format: detectFormat(filepath),
+		opener: opener,
+		opened: opened,
+	}
+}
+
+func (source *fileSource) Name() string {
+	return source.name
+}
+
+func (source *fileSource) Load(_ context.Context) (Format, io.ReadCloser, error) {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	if source.opened != nil {
+		f := source.opened
+		source.opened = nil
+		return source.format, f, nil
+	}
+
+	f, err := source.opener.Open(source.name)
+	if err != nil {
+		if source.optional && errors.Is(err, fs.ErrNotExist) {
+			return "", nil, fmt.Errorf("%w, %w", MissingSourceErr, err)
+		}
+		return "", nil, err
+	}
+
+	return source.format, f, nil
+}
@@ -0,0 +1,285 @@
+package flowconf
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// flagsFormat is the format of the flags source, a list of path=value entries
+// separated by a NUL byte, the path is the Go field path like Database.Host
+const flagsFormat Format = "flags"
+
+func init() {
+	RegisterFormat(flagsFormat, nil, parseFlags)
+}
+
+// FlagSet is the part of *flag.FlagSet used to bind the configuration.
+//
+// A *pflag.FlagSet can be bound through a standard flag.FlagSet with
+// AddGoFlagSet, the flag values also implement pflag.Value
+type FlagSet interface {
+	Var(value flag.Value, name string, usage string)
+}
+
+// Flags are the command-line flags bound to the fields of a configuration
+type Flags struct {
+	values []*flagValue
+}
+
+// BindFlags defines a flag on the flag set for every field of the configuration.
+//
+// The flag of a nested field is prefixed by the name of its parent, the field
+// Database.Host becomes --database.host. A name is the `flag` tag, or else the
+// json, toml or yaml tag name, or else the lowercased field name, and the help
+// text is taken from the `usage` tag. Fields tagged with `flag:"-"` are skipped,
+// like the fields of an embedded struct shadowed by an outer field.
+// It fails if two fields have the same flag name, or if the flag set already
+// has a flag of that name.
+func BindFlags(fs FlagSet, config any) (*Flags, error) {
+	t := reflect.TypeOf(config)
+	if t != nil {
+		t = indirectType(t)
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot bind flags to %T, config needs to be a struct", config)
+	}
+
+	flags := &Flags{}
+	err := flags.bind(fs, t, "", nil, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	return flags, nil
+}
+
+// bind defines the flags of the fields of t, path is the Go field path of t.
+// seen holds the structs being bound so a recursive type does not define flags
+// without end
+func (flags *Flags) bind(fs FlagSet, t reflect.Type, prefix string, path []string, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for _, field := range flagFields(t) {
+		name := prefix + flagName(field)
+		fieldPath := path[:len(path):len(path)]
+		for i := range field.Index {
+			fieldPath = append(fieldPath, t.FieldByIndex(field.Index[:i+1]).Name)
+		}
+
+		ft := indirectType(field.Type)
+		if !isLeafType(ft) {
+			if err := flags.bind(fs, ft, name+".", fieldPath, seen); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !isSettableFromString(field.Type) {
+			continue
+		}
+
+		if flags.defines(fs, name) {
+			return fmt.Errorf("cannot bind %s, the flag --%s is already defined", strings.Join(fieldPath, "."), name)
+		}
+
+		value := &flagValue{
+			name: name,
+			path: strings.Join(fieldPath, "."),
+			sep:  separatorOf(field),
+			typ:  field.Type,
+		}
+		flags.values = append(flags.values, value)
+		fs.Var(value, name, field.Tag.Get("usage"))
+	}
+
+	return nil
+}
+
+// defines reports if the flag name is already bound, or defined on the flag
+// set when it can be looked up
+func (flags *Flags) defines(fs FlagSet, name string) bool {
+	for _, value := range flags.values {
+		if value.name == name {
+			return true
+		}
+	}
+
+	lookup, ok := fs.(interface{ Lookup(name string) *flag.Flag })
+	return ok && lookup.Lookup(name) != nil
+}
+
+// flagFields returns the fields of the struct type t that have a flag, the
+// fields of the embedded structs without a name are promoted following the
+// rules of Go, a field shadows the fields of the same name nested deeper
+func flagFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Tag.Get("flag") == "-" || isPromotingField(field) {
+			continue
+		}
+
+		promoted := true
+		for i := 1; i < len(field.Index); i++ {
+			promoted = promoted && isPromotingField(t.FieldByIndex(field.Index[:i]))
+		}
+		if promoted {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// isPromotingField reports if the field is an embedded struct whose fields have
+// their flags without a prefix
+func isPromotingField(field reflect.StructField) bool {
+	return field.Anonymous &&
+		field.IsExported() &&
+		indirectType(field.Type).Kind() == reflect.Struct &&
+		!hasNameTag(field) &&
+		field.Tag.Get("flag") == ""
+}
+
+// Source returns a source holding the flags that were set on the command-line,
+// the others do not override the previous sources.
+// The flags are read when the source is loaded, so after they are parsed. It is
+// usually the last source of the builder so the command-line has the final say.
+func (flags *Flags) Source() Source {
+	return NewLazySource("flags", func(_ context.Context) (Format, io.ReadCloser, error) {
+		var buf bytes.Buffer
+		for _, value := range flags.values {
+			if !value.set {
+				continue
+			}
+			buf.WriteString(value.path + "=" + value.raw)
+			buf.WriteByte(0)
+		}
+
+		return flagsFormat, io.NopCloser(&buf), nil
+	})
+}
+
+func parseFlags(config any, r io.Reader) error {
+	entries, err := readEnviron(r)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(config)
+	for _, entry := range entries {
+		if entry.value == UnsetMarker {
+			continue
+		}
+
+		field, structField, ok := fieldByPath(rv, strings.Split(entry.key, "."), true)
+		if !ok {
+			return fmt.Errorf("no field %s for a flag", entry.key)
+		}
+
+		err := setFromString(field, entry.value, separatorOf(structField))
+		if err != nil {
+			return fmt.Errorf("failed to set %s from its flag, %w", entry.key, err)
+		}
+	}
+
+	return nil
+}
+
+func flagName(field reflect.StructField) string {
+	if name := field.Tag.Get("flag"); name != "" {
+		return name
+	}
+
+	for _, tag := range nameTags {
+		if name := tagName(field, tag); name != "" {
+			return name
+		}
+	}
+
+	return strings.ToLower(field.Name)
+}
+
+// isSettableFromString reports if setFromString supports the type
+func isSettableFromString(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Pointer, reflect.Slice:
+		return isSettableFromString(t.Elem())
+	}
+
+	return false
+}
+
+// flagValue keeps the raw value of a flag until the source is decoded,
+// it implements flag.Value and pflag.Value
+type flagValue struct {
+	name string
+	// path is the Go field path of the field the flag sets
+	path string
+	sep  string
+	typ  reflect.Type
+	raw  string
+	set  bool
+}
+
+func (value *flagValue) String() string {
+	if value == nil {
+		return ""
+	}
+
+	return value.raw
+}
+
+// Set is called for every occurrence of the flag, the occurrences of a slice
+// flag are appended. The value is converted right away so an invalid value is
+// reported by the flag set
+func (value *flagValue) Set(str string) error {
+	t := indirectType(value.typ)
+	if value.set && t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		str = value.raw + value.sep + str
+	}
+
+	if str != UnsetMarker {
+		err := setFromString(reflect.New(value.typ).Elem(), str, value.sep)
+		if err != nil {
+			return err
+		}
+	}
+
+	value.raw = str
+	value.set = true
+	return nil
+}
+
+func (value *flagValue) Type() string {
+	return indirectType(value.typ).String()
+}
+
+func (value *flagValue) IsBoolFlag() bool {
+	return indirectType(value.typ).Kind() == reflect.Bool
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
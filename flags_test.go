@@ -0,0 +1,227 @@
+package flowconf_test
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type flagsConfig struct {
+	Name     string `usage:"name of the service"`
+	Debug    bool
+	Database struct {
+		Host    string
+		Port    int
+		Timeout time.Duration
+	}
+	AllowedOrigins []string `json:"allowed_origins"`
+	Internal       string   `flag:"-"`
+}
+
+func TestBindFlags_explicitlySetFlagsOverrideOtherSources(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		fs   = flag.NewFlagSet("test", flag.ContinueOnError)
+		args = []string{
+			"--meaningoflife=43",
+			"--TagValue", "from flags",
+		}
+		config = new(test.Config)
+	)
+
+	sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(test.FileSystem, "data/config.toml")
+	assert.NoError(t, err)
+
+	flags, err := flowconf.BindFlags(fs, config)
+	assert.NoError(t, err)
+	assert.NoError(t, fs.Parse(args))
+
+	// /////////////////////// WHEN ///////////////////////
+	err = flowconf.NewBuilder(append(sources, flags.Source())...).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, 43, config.MeaningOfLife)
+	assert.Equal(t, "from flags", config.Tag)
+	// not set on the command-line so the file value stays
+	assert.Equal(t, []string{"James", "Bond"}, config.Cats)
+	assert.Equal(t, 3.14, config.Pi)
+}
+
+func TestBindFlags_nestedFieldsAndTypes(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		fs   = flag.NewFlagSet("test", flag.ContinueOnError)
+		args = []string{
+			"--debug",
+			"--database.host=db.internal",
+			"--database.port=5432",
+			"--database.timeout=2s",
+			"--allowed_origins=a.com,b.com",
+			"--allowed_origins=c.com",
+		}
+		config = new(flagsConfig)
+	)
+
+	flags, err := flowconf.BindFlags(fs, config)
+	assert.NoError(t, err)
+	assert.NoError(t, fs.Parse(args))
+
+	// /////////////////////// WHEN ///////////////////////
+	err = flowconf.NewBuilder(flags.Source()).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.True(t, config.Debug)
+	assert.Equal(t, "db.internal", config.Database.Host)
+	assert.Equal(t, 5432, config.Database.Port)
+	assert.Equal(t, 2*time.Second, config.Database.Timeout)
+	assert.Equal(t, []string{"a.com", "b.com", "c.com"}, config.AllowedOrigins)
+	assert.Nil(t, fs.Lookup("internal"))
+}
+
+func TestBindFlags_usageAndInvalidValues(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		fs     = flag.NewFlagSet("test", flag.ContinueOnError)
+		output bytes.Buffer
+	)
+	fs.SetOutput(&output)
+
+	_, err := flowconf.BindFlags(fs, new(flagsConfig))
+	assert.NoError(t, err)
+
+	// /////////////////////// WHEN ///////////////////////
+	err = fs.Parse([]string{"--database.port=abc"})
+
+	// /////////////////////// THEN ///////////////////////
+	assert.ErrorContains(t, err, "database.port")
+	assert.Contains(t, output.String(), "name of the service")
+}
+
+type flagsNode struct {
+	Value int
+	Next  *flagsNode
+}
+
+func TestBindFlags_recursiveType(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	// /////////////////////// WHEN ///////////////////////
+	_, err := flowconf.BindFlags(fs, new(flagsNode))
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.NotNil(t, fs.Lookup("value"))
+	assert.Nil(t, fs.Lookup("next.value"))
+}
+
+type flagsTaggedConfig struct {
+	Port     int `flag:"listen-port"`
+	Database struct {
+		Host string `flag:"hostname"`
+	}
+}
+
+func TestBindFlags_flagTag(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		fs     = flag.NewFlagSet("test", flag.ContinueOnError)
+		config = new(flagsTaggedConfig)
+	)
+
+	flags, err := flowconf.BindFlags(fs, config)
+	assert.NoError(t, err)
+	assert.NoError(t, fs.Parse([]string{"--listen-port=8080", "--database.hostname=db.internal"}))
+
+	// /////////////////////// WHEN ///////////////////////
+	err = flowconf.NewBuilder(flags.Source()).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, config.Port)
+	assert.Equal(t, "db.internal", config.Database.Host)
+}
+
+type FlagsMeta struct {
+	Name    string
+	Version string
+}
+
+type FlagsRegion struct {
+	Region string
+}
+
+type flagsEmbeddingConfig struct {
+	FlagsMeta
+	*FlagsRegion
+	Name string
+}
+
+func TestBindFlags_embeddedFieldsShadowedByOuterFields(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		fs     = flag.NewFlagSet("test", flag.ContinueOnError)
+		config = new(flagsEmbeddingConfig)
+	)
+
+	flags, err := flowconf.BindFlags(fs, config)
+	assert.NoError(t, err)
+	assert.NoError(t, fs.Parse([]string{"--name=outer", "--version=1.2", "--region=eu"}))
+
+	// /////////////////////// WHEN ///////////////////////
+	err = flowconf.NewBuilder(flags.Source()).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, "outer", config.Name)
+	assert.Empty(t, config.FlagsMeta.Name)
+	assert.Equal(t, "1.2", config.Version)
+	assert.Equal(t, "eu", config.Region)
+}
+
+func TestBindFlags_duplicateNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		defined []string
+		config  any
+		want    string
+	}{
+		{
+			name: "two fields",
+			config: new(struct {
+				Host     string
+				HostName string `flag:"host"`
+			}),
+			want: "cannot bind HostName, the flag --host is already defined",
+		},
+		{
+			name:    "flag of the program",
+			defined: []string{"verbose"},
+			config:  new(struct{ Verbose bool }),
+			want:    "cannot bind Verbose, the flag --verbose is already defined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// /////////////////////// GIVEN ///////////////////////
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			for _, name := range tt.defined {
+				fs.Bool(name, false, "")
+			}
+
+			// /////////////////////// WHEN ///////////////////////
+			_, err := flowconf.BindFlags(fs, tt.config)
+
+			// /////////////////////// THEN ///////////////////////
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
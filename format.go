@@ -0,0 +1,141 @@
+package flowconf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeFunc decodes the content of r into config, overriding the values
+// already set by the previous sources
+type DecodeFunc func(config any, r io.Reader) error
+
+var formats = struct {
+	sync.RWMutex
+	decoders map[Format]DecodeFunc
+	// trees are the decoders of the formats that can also be decoded into a
+	// map[string]any, their documents can be inspected before being decoded
+	// into the configuration
+	trees      map[Format]DecodeFunc
+	extensions map[string]Format
+}{
+	decoders:   map[Format]DecodeFunc{},
+	trees:      map[Format]DecodeFunc{},
+	extensions: map[string]Format{},
+}
+
+// treeFormats are the formats naming the keys with a struct tag of the name of
+// the format, a schema or a dump can be generated for them
+var treeFormats = map[Format]bool{Toml: true, Json: true, Yaml: true}
+
+func init() {
+	registerTreeFormat(Toml, []string{".toml"}, parseTOML)
+	registerTreeFormat(Json, []string{".json"}, parseJSON)
+	registerTreeFormat(Yaml, []string{".yaml", ".yml"}, parseYAML)
+}
+
+// registerTreeFormat registers a format whose decoder also decodes into a
+// map[string]any
+func registerTreeFormat(format Format, extensions []string, decode DecodeFunc) {
+	RegisterFormat(format, extensions, decode)
+
+	formats.Lock()
+	defer formats.Unlock()
+	formats.trees[format] = decode
+}
+
+// RegisterFormat makes a format available to the Builder and associates the
+// file extensions with it, so it is detected by the file loaders.
+//
+// Registering an already known format or extension replaces the previous
+// registration, which makes it possible to swap the decoder of the built-in
+// formats. The documents of a swapped format are only decoded by decode, they
+// are not inspected for includes, tombstones, unknown keys or a schema.
+// It panics if the format is empty or decode is nil.
+func RegisterFormat(format Format, extensions []string, decode DecodeFunc) {
+	if format == "" || format == unknown {
+		panic("flowconf: RegisterFormat format is invalid")
+	}
+	if decode == nil {
+		panic("flowconf: RegisterFormat decode is nil")
+	}
+
+	formats.Lock()
+	defer formats.Unlock()
+
+	formats.decoders[format] = decode
+	delete(formats.trees, format)
+	for _, ext := range extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		formats.extensions[ext] = format
+	}
+}
+
+func decoderFor(format Format) (DecodeFunc, error) {
+	formats.RLock()
+	defer formats.RUnlock()
+
+	decode, ok := formats.decoders[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+
+	return decode, nil
+}
+
+// treeDecoderFor returns the decoder into a map[string]any of the format, or
+// nil if its documents cannot be inspected
+func treeDecoderFor(format Format) DecodeFunc {
+	formats.RLock()
+	defer formats.RUnlock()
+
+	return formats.trees[format]
+}
+
+// detectFormat returns the format registered for the longest extension
+// matching the end of str
+func detectFormat(str string) Format {
+	formats.RLock()
+	defer formats.RUnlock()
+
+	format, matched := unknown, ""
+	for ext, f := range formats.extensions {
+		if strings.HasSuffix(str, ext) && len(ext) > len(matched) {
+			format, matched = f, ext
+		}
+	}
+
+	return format
+}
+
+func parseTOML(config any, r io.Reader) error {
+	_, err := toml.NewDecoder(r).Decode(config)
+	return err
+}
+
+func parseJSON(config any, r io.Reader) error {
+	return json.NewDecoder(r).Decode(config)
+}
+
+// parseYAML decodes every document of the stream in order, so a multi-document
+// file behaves like a cascade of sources on its own
+func parseYAML(config any, r io.Reader) error {
+	decoder := yaml.NewDecoder(r)
+	for {
+		err := decoder.Decode(config)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
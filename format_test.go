@@ -0,0 +1,52 @@
+package flowconf_test
+
+import (
+	"bufio"
+	"io"
+	"testing"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterFormat_customFormatIsDetectedAndDecoded(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		lines = flowconf.Format("lines")
+		// every line of the file is a cat
+		decode = func(config any, r io.Reader) error {
+			conf := config.(*test.Config)
+			conf.Cats = nil
+			scanner := bufio.NewScanner(r)
+			for scanner.Scan() {
+				conf.Cats = append(conf.Cats, scanner.Text())
+			}
+			return scanner.Err()
+		}
+		config = new(test.Config)
+	)
+
+	flowconf.RegisterFormat(lines, []string{"lines"}, decode)
+
+	sources, err := flowconf.NewSourcesFromEmbeddedFileSystem(
+		test.FileSystem,
+		"data/config.toml",
+		"data/cats.lines",
+	)
+	assert.NoError(t, err)
+
+	// /////////////////////// WHEN ///////////////////////
+	err = flowconf.NewBuilder(sources...).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, 42, config.MeaningOfLife)
+	assert.Equal(t, []string{"Garfield", "Tom"}, config.Cats)
+}
+
+func TestRegisterFormat_panicsOnNilDecoder(t *testing.T) {
+	assert.Panics(t, func() {
+		flowconf.RegisterFormat("nil", []string{".nil"}, nil)
+	})
+}
@@ -0,0 +1,118 @@
+package flowconf
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// lister is the file system used to expand the directories and glob patterns
+type lister interface {
+	Glob(pattern string) ([]string, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Join(elem ...string) string
+}
+
+type osLister struct{}
+
+func (osLister) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+func (osLister) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osLister) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osLister) Join(elem ...string) string {
+	return filepath.Join(elem...)
+}
+
+type fsLister struct {
+	fsys fs.FS
+}
+
+func (l fsLister) Glob(pattern string) ([]string, error) {
+	return fs.Glob(l.fsys, pattern)
+}
+
+func (l fsLister) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(l.fsys, name)
+}
+
+func (l fsLister) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(l.fsys, name)
+}
+
+func (l fsLister) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+// NewSourcesFromGlob loads the files matching the patterns, in the order of the
+// patterns and then in lexical order. A pattern is a directory, for all the
+// files it contains, or a glob pattern like "conf.d/*.toml".
+//
+// Files whose format is not known are ignored, so are sub directories.
+// A pattern matching no file is not an error.
+//
+//	// conf.d/10-base.toml, conf.d/20-db.json, conf.d/30-local.yaml
+//	sources, err := flowconf.NewSourcesFromGlob("conf.d")
+func NewSourcesFromGlob(patterns ...string) ([]Source, error) {
+	filepaths, err := expandPatterns(osLister{}, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSourcesFromFilepaths(filepaths...)
+}
+
+func expandPatterns(l lister, patterns []string) ([]string, error) {
+	var filepaths []string
+	for _, pattern := range patterns {
+		matches, err := expandPattern(l, pattern)
+		if err != nil {
+			return nil, err
+		}
+		filepaths = append(filepaths, matches...)
+	}
+
+	return filepaths, nil
+}
+
+func expandPattern(l lister, pattern string) ([]string, error) {
+	var candidates []string
+	if info, err := l.Stat(pattern); err == nil && info.IsDir() {
+		entries, err := l.ReadDir(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			candidates = append(candidates, l.Join(pattern, entry.Name()))
+		}
+	} else {
+		candidates, err = l.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if detectFormat(candidate) == unknown {
+			continue
+		}
+		if info, err := l.Stat(candidate); err != nil || info.IsDir() {
+			continue
+		}
+		matches = append(matches, candidate)
+	}
+	sort.Strings(matches)
+
+	return matches, nil
+}
@@ -0,0 +1,111 @@
+package flowconf_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSourcesFromGlob(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	dir := t.TempDir()
+	files := map[string]string{
+		"conf.d/20-db.json":      `{"Pi": 3.1416, "Secret": "from 20-db.json"}`,
+		"conf.d/10-base.toml":    "MeaningOfLife = 42\nPi = 3.14\nSecret = 'from 10-base.toml'",
+		"conf.d/30-local.yaml":   "secret: from 30-local.yaml",
+		"conf.d/README.md":       "not a configuration",
+		"conf.d/sub/99-sub.json": `{"Secret": "from a sub directory"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	tests := []struct {
+		name      string
+		patterns  []string
+		wantNames []string
+		want      *test.Config
+	}{
+		{
+			name:     "directory in lexical order",
+			patterns: []string{filepath.Join(dir, "conf.d")},
+			wantNames: []string{
+				filepath.Join(dir, "conf.d/10-base.toml"),
+				filepath.Join(dir, "conf.d/20-db.json"),
+				filepath.Join(dir, "conf.d/30-local.yaml"),
+			},
+			want: &test.Config{MeaningOfLife: 42, Pi: 3.1416, Secret: "from 30-local.yaml"},
+		},
+		{
+			name: "glob patterns in the given order",
+			patterns: []string{
+				filepath.Join(dir, "conf.d/*.json"),
+				filepath.Join(dir, "conf.d/*.toml"),
+			},
+			wantNames: []string{
+				filepath.Join(dir, "conf.d/20-db.json"),
+				filepath.Join(dir, "conf.d/10-base.toml"),
+			},
+			want: &test.Config{MeaningOfLife: 42, Pi: 3.14, Secret: "from 10-base.toml"},
+		},
+		{
+			name:      "no match",
+			patterns:  []string{filepath.Join(dir, "conf.d/*.ini")},
+			wantNames: nil,
+			want:      &test.Config{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				sources, err := flowconf.NewSourcesFromGlob(tt.patterns...)
+				assert.NoError(t, err)
+
+				var names []string
+				for _, source := range sources {
+					names = append(names, source.Name())
+				}
+				assert.Equal(t, tt.wantNames, names)
+
+				config := new(test.Config)
+				assert.NoError(t, flowconf.NewBuilder(sources...).Build(config))
+				assert.Equal(t, tt.want, config)
+			},
+		)
+	}
+}
+
+func TestNewSourcesFromFS(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	fsys := fstest.MapFS{
+		"base.json":           {Data: []byte(`{"MeaningOfLife": 42, "Secret": "from base.json"}`)},
+		"conf.d/10-db.toml":   {Data: []byte(`Pi = 3.14`)},
+		"conf.d/20-db.toml":   {Data: []byte(`Pi = 3.1416`)},
+		"conf.d/notes.txt":    {Data: []byte(`not a configuration`)},
+		"overlay/secret.yaml": {Data: []byte(`secret: from overlay/secret.yaml`)},
+	}
+
+	// /////////////////////// WHEN ///////////////////////
+	sources, err := flowconf.NewSourcesFromFS(fsys, "base.json", "conf.d/*", "overlay", "local.json?")
+	assert.NoError(t, err)
+
+	config := new(test.Config)
+	err = flowconf.NewBuilder(sources...).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+
+	var names []string
+	for _, source := range sources {
+		names = append(names, source.Name())
+	}
+	assert.Equal(t, []string{"base.json", "conf.d/10-db.toml", "conf.d/20-db.toml", "overlay/secret.yaml", "local.json"}, names)
+	assert.Equal(t, &test.Config{MeaningOfLife: 42, Pi: 3.1416, Secret: "from overlay/secret.yaml"}, config)
+}
@@ -0,0 +1,45 @@
+package flowconf
+
+import (
+	"context"
+	"fmt"
+)
+
+// Hook is called by the Builder at a stage of the build with the configuration
+// being built, an error aborts the build
+type Hook func(ctx context.Context, config any) error
+
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// AddBeforeDecodeHook adds a hook called before the sources are decoded, once
+// the default values are set
+func (builder *Builder) AddBeforeDecodeHook(name string, hook Hook) {
+	builder.beforeDecode = append(builder.beforeDecode, namedHook{name: name, hook: hook})
+}
+
+// AddAfterMergeHook adds a hook called once every source is decoded, before
+// the secrets are resolved
+func (builder *Builder) AddAfterMergeHook(name string, hook Hook) {
+	builder.afterMerge = append(builder.afterMerge, namedHook{name: name, hook: hook})
+}
+
+// AddAfterSecretsHook adds a hook called once the secrets are resolved, before
+// the required fields and the validation rules are checked. It can derive
+// fields from the secrets, like a DSN
+func (builder *Builder) AddAfterSecretsHook(name string, hook Hook) {
+	builder.afterSecrets = append(builder.afterSecrets, namedHook{name: name, hook: hook})
+}
+
+// runHooks calls the hooks in the order they were added
+func runHooks(ctx context.Context, config any, hooks []namedHook) error {
+	for _, h := range hooks {
+		if err := h.hook(ctx, config); err != nil {
+			return fmt.Errorf("hook %s failed, %w", h.name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package flowconf_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type hookConfig struct {
+	Host     string `default:"localhost"`
+	User     string
+	Password string
+	DSN      string `required:"true"`
+}
+
+func TestBuilder_Build_callsTheHooksAtEveryStage(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		source = flowconf.NewSource("config.json", flowconf.Json, io.NopCloser(strings.NewReader(
+			`{"User": "app", "Password": "@manager::password"}`,
+		)))
+		managerMock = new(test.SecretManagerMock)
+		config      = new(hookConfig)
+		calls       []string
+	)
+
+	managerMock.On("Prefix").Return("manager")
+	managerMock.On("Secret", mock.Anything, "password").Return("secret", nil)
+
+	builder := flowconf.NewBuilder(source)
+	builder.SetSecretManagers(managerMock)
+	builder.AddBeforeDecodeHook("before", func(_ context.Context, config any) error {
+		c := config.(*hookConfig)
+		calls = append(calls, "before: "+c.Host+" "+c.User)
+		return nil
+	})
+	builder.AddAfterMergeHook("merged", func(_ context.Context, config any) error {
+		c := config.(*hookConfig)
+		calls = append(calls, "merged: "+c.User+" "+c.Password)
+		return nil
+	})
+	builder.AddAfterSecretsHook("dsn", func(_ context.Context, config any) error {
+		c := config.(*hookConfig)
+		c.DSN = fmt.Sprintf("%s:%s@%s", c.User, c.Password, c.Host)
+		return nil
+	})
+	builder.AddAfterSecretsHook("normalize", func(_ context.Context, config any) error {
+		c := config.(*hookConfig)
+		c.DSN = strings.ToUpper(c.DSN)
+		return nil
+	})
+
+	// /////////////////////// WHEN ///////////////////////
+	err := builder.Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"before: localhost ", "merged: app @manager::password"}, calls)
+	assert.Equal(t, "APP:SECRET@LOCALHOST", config.DSN)
+}
+
+func TestBuilder_Build_hookErrorAbortsTheBuild(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		hookErr = errors.New("boom")
+		called  bool
+	)
+
+	builder := flowconf.NewBuilder()
+	builder.AddAfterMergeHook("normalize", func(_ context.Context, _ any) error {
+		return hookErr
+	})
+	builder.AddAfterSecretsHook("dsn", func(_ context.Context, _ any) error {
+		called = true
+		return nil
+	})
+
+	// /////////////////////// WHEN ///////////////////////
+	err := builder.Build(new(hookConfig))
+
+	// /////////////////////// THEN ///////////////////////
+	assert.ErrorIs(t, err, hookErr)
+	assert.EqualError(t, err, "hook normalize failed, boom")
+	assert.False(t, called)
+}
@@ -0,0 +1,181 @@
+package flowconf
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// docKey is a key set by a document and the field it leads to
+type docKey struct {
+	// key is the key as written in the document, the keys of nested tables
+	// are joined by dots
+	key string
+	// path is the path of Go field names, it ends with the key of a map entry
+	// when the field is a map. It is nil when no field matches the key.
+	path []string
+	// value is the value in the document, a string for the flat formats
+	value any
+}
+
+// keys returns the leaf keys set by the document on a configuration of type t,
+// in a deterministic order. The documents of a format that is neither a tree
+// nor a flat format have no keys.
+func (doc *document) keys(t reflect.Type) ([]docKey, error) {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	if flat, ok := flatFormats[doc.format]; ok {
+		entries, err := flat.read(bytes.NewReader(doc.content))
+		if err != nil {
+			return nil, err
+		}
+
+		keys := make([]docKey, 0, len(entries))
+		for _, entry := range entries {
+			path, _ := resolvePath(t, strings.Split(entry.key, flat.sep))
+			keys = append(keys, docKey{key: entry.key, path: path, value: entry.value})
+		}
+		return keys, nil
+	}
+
+	tree, err := doc.tree()
+	if err != nil || tree == nil {
+		return nil, err
+	}
+
+	return treeKeys(t, tree, doc.format, "", nil), nil
+}
+
+// treeKeys walks the tree along the struct type t
+func treeKeys(t reflect.Type, tree map[string]any, format Format, prefix string, path []string) []docKey {
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var keys []docKey
+	for _, name := range names {
+		value := tree[name]
+		key := prefix + name
+
+		fieldPath, field, ok := treeField(t, name, format)
+		if !ok {
+			keys = append(keys, docKey{key: key, value: value})
+			continue
+		}
+		fieldPath = append(path[:len(path):len(path)], fieldPath...)
+
+		ft := indirectType(field.Type)
+		nested, isMap := value.(map[string]any)
+		switch {
+		case isMap && ft.Kind() == reflect.Struct && !isLeafType(ft):
+			keys = append(keys, treeKeys(ft, nested, format, key+".", fieldPath)...)
+		case isMap && ft.Kind() == reflect.Map:
+			entries := make([]string, 0, len(nested))
+			for entry := range nested {
+				entries = append(entries, entry)
+			}
+			sort.Strings(entries)
+
+			for _, entry := range entries {
+				keys = append(keys, docKey{
+					key:   key + "." + entry,
+					path:  append(fieldPath[:len(fieldPath):len(fieldPath)], entry),
+					value: nested[entry],
+				})
+			}
+		default:
+			keys = append(keys, docKey{key: key, path: fieldPath, value: value})
+			if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+				keys = append(keys, elementKeys(indirectType(ft.Elem()), value, format, key)...)
+			}
+		}
+	}
+
+	return keys
+}
+
+// elementKeys returns the unknown keys of the elements of a list of structs,
+// like an array of tables, the known keys are set by the list itself
+func elementKeys(t reflect.Type, value any, format Format, key string) []docKey {
+	if t.Kind() != reflect.Struct || isLeafType(t) {
+		return nil
+	}
+
+	var elements []map[string]any
+	switch list := value.(type) {
+	case []map[string]any:
+		elements = list
+	case []any:
+		for _, item := range list {
+			element, _ := item.(map[string]any)
+			elements = append(elements, element)
+		}
+	}
+
+	var keys []docKey
+	for i, element := range elements {
+		for _, k := range treeKeys(t, element, format, fmt.Sprintf("%s[%d].", key, i), nil) {
+			if k.path == nil {
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	return keys
+}
+
+// treeField finds the field of the struct type t named by the key, following
+// the rules of the decoder of the format: the tag named after the format, or
+// else the field name. YAML compares the names exactly, with the field name in
+// lowercase, the others ignore the case.
+func treeField(t reflect.Type, key string, format Format) ([]string, reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get(string(format))
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		inline := format == Yaml && strings.Contains(opts, "inline") ||
+			format != Yaml && field.Anonymous && name == ""
+		if inline && indirectType(field.Type).Kind() == reflect.Struct {
+			if path, f, ok := treeField(indirectType(field.Type), key, format); ok {
+				return append([]string{field.Name}, path...), f, true
+			}
+			continue
+		}
+
+		switch {
+		case format == Yaml && name != "":
+			if key == name {
+				return []string{field.Name}, field, true
+			}
+		case format == Yaml:
+			if key == strings.ToLower(field.Name) {
+				return []string{field.Name}, field, true
+			}
+		case name != "":
+			if strings.EqualFold(key, name) {
+				return []string{field.Name}, field, true
+			}
+		default:
+			if strings.EqualFold(key, field.Name) {
+				return []string{field.Name}, field, true
+			}
+		}
+	}
+
+	return nil, reflect.StructField{}, false
+}
@@ -0,0 +1,226 @@
+package flowconf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MergeStrategy is how the value of a source is merged with the value of the
+// previous sources, it is set on a field with the `flowconf:"merge=<strategy>"` tag.
+//
+// Without a strategy a slice is replaced and the keys of a map are added to
+// the previous ones, whatever the format of the sources
+type MergeStrategy string
+
+const (
+	// MergeReplace replaces the whole slice or map
+	MergeReplace MergeStrategy = "replace"
+	// MergeAppend appends the elements of a slice to the previous ones, or adds
+	// the keys of a map to the previous ones
+	MergeAppend MergeStrategy = "append"
+	// MergeDeep merges the maps recursively, the nested maps are merged
+	// instead of replaced
+	MergeDeep MergeStrategy = "deep"
+)
+
+// stashedField is the value of a field with a merge strategy, before a
+// document is decoded
+type stashedField struct {
+	strategy MergeStrategy
+	value    reflect.Value
+	previous reflect.Value
+}
+
+// decodeDocument decodes the document with the merge strategies. The documents
+// of a YAML stream are merged one after the other, like a cascade of sources,
+// unless the YAML decoder was swapped
+func decodeDocument(config any, doc *document) error {
+	if doc.format != Yaml || !doc.hasTree() {
+		return decodeWithMergeStrategies(config, doc.decode)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(doc.content))
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = decodeWithMergeStrategies(config, func(config any) error {
+			return node.Decode(config)
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// decodeWithMergeStrategies calls decode and merges the fields with a merge
+// strategy. These fields are set to their zero value before decoding, so a
+// field set by the document is not nil afterward and is merged with its
+// previous value, the others get their previous value back.
+func decodeWithMergeStrategies(config any, decode func(config any) error) error {
+	stashed, err := stashMergedFields(reflect.ValueOf(config), "", nil)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range stashed {
+		field.value.Set(reflect.Zero(field.value.Type()))
+	}
+
+	err = decode(config)
+
+	for _, field := range stashed {
+		field.merge()
+	}
+
+	return err
+}
+
+func (field stashedField) merge() {
+	current := field.value
+	if current.IsNil() {
+		current.Set(field.previous)
+		return
+	}
+	if field.previous.IsNil() {
+		return
+	}
+
+	switch field.strategy {
+	case MergeAppend:
+		if current.Kind() == reflect.Slice {
+			current.Set(reflect.AppendSlice(cloneSlice(field.previous), current))
+			return
+		}
+		current.Set(mergeMaps(field.previous, current, false))
+	case MergeDeep:
+		current.Set(mergeMaps(field.previous, current, true))
+	}
+}
+
+// stashMergedFields returns the fields of v with a merge strategy, with their value
+func stashMergedFields(v reflect.Value, prefix string, stashed []stashedField) ([]stashedField, error) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return stashed, nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct || isLeafType(v.Type()) {
+		return stashed, nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		path := prefix + field.Name
+		strategy, ok := flowconfOption(field, "merge")
+		if !ok {
+			var err error
+			stashed, err = stashMergedFields(v.Field(i), path+".", stashed)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := checkMergeStrategy(MergeStrategy(strategy), field.Type.Kind()); err != nil {
+			return nil, fmt.Errorf("invalid merge strategy on %s, %w", path, err)
+		}
+
+		stashed = append(stashed, stashedField{
+			strategy: MergeStrategy(strategy),
+			value:    v.Field(i),
+			previous: reflect.ValueOf(v.Field(i).Interface()),
+		})
+	}
+
+	return stashed, nil
+}
+
+func checkMergeStrategy(strategy MergeStrategy, kind reflect.Kind) error {
+	switch {
+	case kind != reflect.Slice && kind != reflect.Map:
+		return fmt.Errorf("merge strategies only apply to slices and maps, not %s", kind)
+	case strategy == MergeReplace, strategy == MergeAppend:
+		return nil
+	case strategy == MergeDeep && kind == reflect.Map:
+		return nil
+	case strategy == MergeDeep:
+		return fmt.Errorf("the deep merge strategy only applies to maps")
+	}
+
+	return fmt.Errorf("unknown merge strategy: %s", strategy)
+}
+
+func cloneSlice(slice reflect.Value) reflect.Value {
+	clone := reflect.MakeSlice(slice.Type(), slice.Len(), slice.Len())
+	reflect.Copy(clone, slice)
+	return clone
+}
+
+// mergeMaps returns a new map with the keys of previous and current, the
+// values of current win. When deep is true, the nested maps present in both
+// are merged too
+func mergeMaps(previous reflect.Value, current reflect.Value, deep bool) reflect.Value {
+	merged := reflect.MakeMapWithSize(current.Type(), previous.Len()+current.Len())
+
+	iter := previous.MapRange()
+	for iter.Next() {
+		merged.SetMapIndex(iter.Key(), iter.Value())
+	}
+
+	iter = current.MapRange()
+	for iter.Next() {
+		value := iter.Value()
+		if deep {
+			if old := merged.MapIndex(iter.Key()); old.IsValid() {
+				value = mergeNested(old, value)
+			}
+		}
+		merged.SetMapIndex(iter.Key(), value)
+	}
+
+	return merged
+}
+
+// mergeNested merges two map values, interfaces included, or returns current
+// if they are not both maps
+func mergeNested(previous reflect.Value, current reflect.Value) reflect.Value {
+	p, c := previous, current
+	for p.Kind() == reflect.Interface && !p.IsNil() {
+		p = p.Elem()
+	}
+	for c.Kind() == reflect.Interface && !c.IsNil() {
+		c = c.Elem()
+	}
+
+	if p.Kind() != reflect.Map || c.Kind() != reflect.Map || p.Type() != c.Type() || p.IsNil() || c.IsNil() {
+		return current
+	}
+
+	merged := mergeMaps(p, c, true)
+	if current.Kind() == reflect.Interface {
+		wrapped := reflect.New(current.Type()).Elem()
+		wrapped.Set(merged)
+		return wrapped
+	}
+
+	return merged
+}
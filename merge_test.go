@@ -0,0 +1,98 @@
+package flowconf_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/stretchr/testify/assert"
+)
+
+type mergeConfig struct {
+	Hosts          []string
+	AllowedOrigins []string          `flowconf:"merge=append"`
+	Labels         map[string]string `flowconf:"merge=replace"`
+	Limits         map[string]int    `flowconf:"merge=append"`
+	Routes         map[string]map[string]string
+	Features       map[string]map[string]bool `flowconf:"merge=deep"`
+	Server         struct {
+		Tags []string `flowconf:"merge=append"`
+	}
+}
+
+func TestBuilder_Build_mergeStrategies(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	t.Setenv("FLOWCONF_TEST_ALLOWED_ORIGINS", "env.com")
+
+	var (
+		base = flowconf.NewSource("base.toml", flowconf.Toml, io.NopCloser(strings.NewReader(`
+Hosts = [ "a", "b" ]
+AllowedOrigins = [ "a.com" ]
+Labels = { team = "core", tier = "1" }
+Limits = { cpu = 1, memory = 2 }
+Routes = { api = { v1 = "/v1" } }
+Features = { search = { fuzzy = true } }
+Server = { Tags = [ "base" ] }
+`)))
+		overlay = flowconf.NewSource("prod.json", flowconf.Json, io.NopCloser(strings.NewReader(`{
+	"Hosts": ["c"],
+	"AllowedOrigins": ["b.com"],
+	"Labels": {"tier": "2"},
+	"Limits": {"memory": 4},
+	"Routes": {"api": {"v2": "/v2"}},
+	"Features": {"search": {"stemming": true}},
+	"Server": {"Tags": ["prod"]}
+}`)))
+		untouched = flowconf.NewSource("untouched.yaml", flowconf.Yaml, io.NopCloser(strings.NewReader(`hosts: [ "d" ]`)))
+		config    = new(mergeConfig)
+	)
+
+	// /////////////////////// WHEN ///////////////////////
+	err := flowconf.NewBuilder(base, overlay, flowconf.NewSourceFromEnv("FLOWCONF_TEST"), untouched).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, config.Hosts)
+	assert.Equal(t, []string{"a.com", "b.com", "env.com"}, config.AllowedOrigins)
+	assert.Equal(t, map[string]string{"tier": "2"}, config.Labels)
+	assert.Equal(t, map[string]int{"cpu": 1, "memory": 4}, config.Limits)
+	assert.Equal(t, map[string]map[string]string{"api": {"v2": "/v2"}}, config.Routes)
+	assert.Equal(t, map[string]map[string]bool{"search": {"fuzzy": true, "stemming": true}}, config.Features)
+	assert.Equal(t, []string{"base", "prod"}, config.Server.Tags)
+}
+
+func TestBuilder_Build_mergeStrategiesOfYAMLDocuments(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		source = flowconf.NewSource("config.yaml", flowconf.Yaml, io.NopCloser(strings.NewReader(`
+allowedorigins: [ "a.com" ]
+labels: { team: core }
+---
+allowedorigins: [ "b.com" ]
+labels: { tier: "2" }
+`)))
+		config = new(mergeConfig)
+	)
+
+	// /////////////////////// WHEN ///////////////////////
+	err := flowconf.NewBuilder(source).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.com", "b.com"}, config.AllowedOrigins)
+	assert.Equal(t, map[string]string{"tier": "2"}, config.Labels)
+}
+
+func TestBuilder_Build_invalidMergeStrategy(t *testing.T) {
+	type config struct {
+		Name  string   `flowconf:"merge=append"`
+		Hosts []string `flowconf:"merge=deep"`
+	}
+
+	source := flowconf.NewSource("base.json", flowconf.Json, io.NopCloser(strings.NewReader(`{}`)))
+
+	err := flowconf.NewBuilder(source).Build(new(config))
+
+	assert.ErrorContains(t, err, "invalid merge strategy on Name")
+}
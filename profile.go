@@ -0,0 +1,105 @@
+package flowconf
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ProfileEnv is the environment variable holding the comma separated list of
+// active profiles when none is given to ResolveProfiles
+const ProfileEnv = "FLOWCONF_PROFILE"
+
+// localProfile is always the last profile of the chain, for the overrides of
+// the developers that are not committed
+const localProfile = "local"
+
+// ProfileChain is the list of files overriding a base configuration file for
+// the active profiles
+type ProfileChain struct {
+	Base     string
+	Profiles []string
+	// Files are the files found for the chain, in the order they are applied
+	Files []string
+}
+
+// ResolveProfiles finds the files of the override chain of the base
+//
+//	<base>.<ext>
+//	<base>.<profile>.<ext> for every profile, in order
+//	<base>.local.<ext>
+//
+// where <ext> is any of the registered extensions. The base must exist, the
+// files of the profiles are optional. When no profile is given, they are read
+// from the FLOWCONF_PROFILE environment variable.
+//
+//	// FLOWCONF_PROFILE=prod loads config/app.toml, config/app.prod.toml and config/app.local.toml if they exist
+//	chain, err := flowconf.ResolveProfiles("config/app")
+func ResolveProfiles(base string, profiles ...string) (*ProfileChain, error) {
+	if len(profiles) == 0 {
+		profiles = splitProfiles(os.Getenv(ProfileEnv))
+	}
+
+	chain := &ProfileChain{Base: base, Profiles: profiles}
+
+	chain.Files = findWithExtensions(base)
+	if len(chain.Files) == 0 {
+		return nil, fmt.Errorf("no configuration file found for the base: %s", base)
+	}
+
+	for _, profile := range profiles {
+		chain.Files = append(chain.Files, findWithExtensions(base+"."+profile)...)
+	}
+	chain.Files = append(chain.Files, findWithExtensions(base+"."+localProfile)...)
+
+	return chain, nil
+}
+
+// Sources returns the sources of the files of the chain
+func (chain *ProfileChain) Sources() ([]Source, error) {
+	return NewSourcesFromFilepaths(chain.Files...)
+}
+
+// NewSourcesFromProfiles returns the sources of the override chain of the base,
+// see ResolveProfiles
+func NewSourcesFromProfiles(base string, profiles ...string) ([]Source, error) {
+	chain, err := ResolveProfiles(base, profiles...)
+	if err != nil {
+		return nil, err
+	}
+
+	return chain.Sources()
+}
+
+func splitProfiles(str string) []string {
+	var profiles []string
+	for _, profile := range strings.Split(str, ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+
+	return profiles
+}
+
+// findWithExtensions returns the existing files named name with any of the
+// registered extensions, in lexical order
+func findWithExtensions(name string) []string {
+	formats.RLock()
+	var candidates []string
+	for ext := range formats.extensions {
+		candidates = append(candidates, name+ext)
+	}
+	formats.RUnlock()
+
+	var found []string
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			found = append(found, candidate)
+		}
+	}
+	sort.Strings(found)
+
+	return found
+}
@@ -0,0 +1,88 @@
+package flowconf_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveProfiles(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.toml":       "MeaningOfLife = 42\nPi = 3.14\nSecret = 'from app.toml'",
+		"app.prod.json":  `{"Secret": "from app.prod.json"}`,
+		"app.test.json":  `{"Secret": "from app.test.json"}`,
+		"app.local.yaml": "pi: 3.1416",
+	}
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	base := filepath.Join(dir, "app")
+
+	tests := []struct {
+		name      string
+		env       string
+		profiles  []string
+		wantFiles []string
+		want      *test.Config
+	}{
+		{
+			name:     "profiles from the arguments",
+			profiles: []string{"staging", "prod"},
+			wantFiles: []string{
+				filepath.Join(dir, "app.toml"),
+				filepath.Join(dir, "app.prod.json"),
+				filepath.Join(dir, "app.local.yaml"),
+			},
+			want: &test.Config{MeaningOfLife: 42, Pi: 3.1416, Secret: "from app.prod.json"},
+		},
+		{
+			name: "profiles from the environment",
+			env:  "test, prod",
+			wantFiles: []string{
+				filepath.Join(dir, "app.toml"),
+				filepath.Join(dir, "app.test.json"),
+				filepath.Join(dir, "app.prod.json"),
+				filepath.Join(dir, "app.local.yaml"),
+			},
+			want: &test.Config{MeaningOfLife: 42, Pi: 3.1416, Secret: "from app.prod.json"},
+		},
+		{
+			name: "no profile",
+			wantFiles: []string{
+				filepath.Join(dir, "app.toml"),
+				filepath.Join(dir, "app.local.yaml"),
+			},
+			want: &test.Config{MeaningOfLife: 42, Pi: 3.1416, Secret: "from app.toml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				t.Setenv(flowconf.ProfileEnv, tt.env)
+
+				chain, err := flowconf.ResolveProfiles(base, tt.profiles...)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantFiles, chain.Files)
+
+				sources, err := chain.Sources()
+				assert.NoError(t, err)
+
+				config := new(test.Config)
+				assert.NoError(t, flowconf.NewBuilder(sources...).Build(config))
+				assert.Equal(t, tt.want, config)
+			},
+		)
+	}
+}
+
+func TestResolveProfiles_failsWithoutBase(t *testing.T) {
+	_, err := flowconf.ResolveProfiles(filepath.Join(t.TempDir(), "app"), "prod")
+
+	assert.ErrorContains(t, err, "no configuration file found")
+}
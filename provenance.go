@@ -0,0 +1,166 @@
+package flowconf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DefaultSource is the source of the fields set by their `default` tag
+const DefaultSource = "default"
+
+// Origin tells where the value of a field comes from
+type Origin struct {
+	// Source is the name of the source that last set the field, or
+	// DefaultSource. A source that unsets the field is its origin too
+	Source string
+	// Secrets are the references, like "@gcp::name", resolved by a
+	// SecretManager in the value of the field, the secrets are not kept
+	Secrets []string
+}
+
+// Provenance returns the origin of every field set by the last build, keyed by
+// the path of the field, like "Database.Host". The entries of a map are set on
+// their own, like "Labels.team", the elements of a slice are not.
+//
+// The fields changed by a Hook are not tracked
+func (builder *Builder) Provenance() map[string]Origin {
+	builder.mu.Lock()
+	defer builder.mu.Unlock()
+
+	provenance := make(map[string]Origin, len(builder.provenance))
+	for path, origin := range builder.provenance {
+		provenance[path] = origin
+	}
+
+	return provenance
+}
+
+func (builder *Builder) setProvenance(provenance map[string]Origin) {
+	builder.mu.Lock()
+	defer builder.mu.Unlock()
+
+	builder.provenance = provenance
+}
+
+// record sets the origin of the field, the fields nested in it were replaced
+// by the source too
+func record(provenance map[string]Origin, path string, source string) {
+	forget(provenance, path)
+	provenance[path] = Origin{Source: source}
+}
+
+// forget removes the origin of the fields nested in the field
+func forget(provenance map[string]Origin, path string) {
+	for p := range provenance {
+		if strings.HasPrefix(p, path+".") {
+			delete(provenance, p)
+		}
+	}
+}
+
+// replacedMaps returns the paths of the maps of the struct type t with the
+// replace merge strategy that the keys set, their previous entries are gone
+func replacedMaps(t reflect.Type, keys []docKey) map[string]bool {
+	replaced := map[string]bool{}
+	for _, key := range keys {
+		st := indirectType(t)
+		for i := 0; i < len(key.path)-1 && st.Kind() == reflect.Struct; i++ {
+			field, ok := st.FieldByName(key.path[i])
+			if !ok {
+				break
+			}
+			if field.Type.Kind() == reflect.Map {
+				if strategy, _ := flowconfOption(field, "merge"); MergeStrategy(strategy) == MergeReplace {
+					replaced[strings.Join(key.path[:i+1], ".")] = true
+				}
+				break
+			}
+			st = indirectType(field.Type)
+		}
+	}
+
+	return replaced
+}
+
+// recordSecrets adds the references found in the fields to their origin
+func recordSecrets(provenance map[string]Origin, references []reference) {
+	for _, ref := range references {
+		path := ref.fieldPath()
+		origin := provenance[path]
+		origin.Secrets = append(origin.Secrets, ref.value)
+		provenance[path] = origin
+	}
+}
+
+// reference is a reference to a secret found in a string of the configuration
+type reference struct {
+	// path is the path of the string, a slice element has its index, like
+	// Servers[1].Password
+	path string
+	// value is the reference, like @gcp::name
+	value   string
+	manager string
+	key     string
+}
+
+// fieldPath returns the path of the field holding the string, the elements of
+// a slice are held by the slice, like Servers for Servers[1].Password
+func (ref reference) fieldPath() string {
+	if i := strings.Index(ref.path, "["); i >= 0 {
+		return ref.path[:i]
+	}
+
+	return ref.path
+}
+
+// findReferences walks the configuration and returns the references to a
+// secret of its strings, in a deterministic order
+func findReferences(v reflect.Value, path string) []reference {
+	if v.Type() == secretType {
+		return findReferences(reflect.ValueOf(v.Interface().(Secret).value), path)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return findReferences(v.Elem(), path)
+	case reflect.String:
+		var refs []reference
+		for _, match := range managerReg.FindAllStringSubmatch(v.String(), -1) {
+			refs = append(refs, reference{path: path, value: match[0], manager: match[1], key: match[2]})
+		}
+		return refs
+	case reflect.Struct:
+		var refs []reference
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				refs = append(refs, findReferences(v.Field(i), joinPath(path, t.Field(i).Name))...)
+			}
+		}
+		return refs
+	case reflect.Slice, reflect.Array:
+		var refs []reference
+		for i := 0; i < v.Len(); i++ {
+			refs = append(refs, findReferences(v.Index(i), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return refs
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+
+		var refs []reference
+		for _, key := range keys {
+			refs = append(refs, findReferences(v.MapIndex(key), joinPath(path, fmt.Sprint(key)))...)
+		}
+		return refs
+	}
+
+	return nil
+}
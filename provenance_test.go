@@ -0,0 +1,110 @@
+package flowconf_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type provenanceConfig struct {
+	Name     string `default:"app"`
+	Port     int    `default:"8080"`
+	Database struct {
+		Host     string
+		Password string
+	}
+	Cache *struct {
+		Size int
+	}
+	Hosts   []string
+	Labels  map[string]string
+	Tags    map[string]string `flowconf:"merge=replace"`
+	Servers []struct {
+		Password string
+	}
+}
+
+func TestBuilder_Provenance(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	t.Setenv("FLOWCONF_PROVENANCE_PORT", "9090")
+
+	fsys := fstest.MapFS{
+		"base.toml": {Data: []byte(`
+include = [ "db.json" ]
+Hosts = [ "a", "@manager::host" ]
+[Cache]
+Size = 10
+[Labels]
+team = "core"
+[Tags]
+tier = "backend"
+[[Servers]]
+Password = "@manager::server"
+`)},
+		"db.json":    {Data: []byte(`{"Database": {"Host": "db", "Password": "@manager::password"}}`)},
+		"local.yaml": {Data: []byte("cache: null\nlabels:\n  env: dev\ntags:\n  owner: ops")},
+	}
+
+	sources, err := flowconf.NewSourcesFromFS(fsys, "base.toml", "local.yaml")
+	assert.NoError(t, err)
+
+	managerMock := new(test.SecretManagerMock)
+	managerMock.On("Prefix").Return("manager")
+	managerMock.On("Secret", mock.Anything, "password").Return("very secret", nil)
+	managerMock.On("Secret", mock.Anything, "host").Return("b", nil)
+	managerMock.On("Secret", mock.Anything, "server").Return("server secret", nil)
+
+	builder := flowconf.NewBuilder(append(sources, flowconf.NewSourceFromEnv("FLOWCONF_PROVENANCE"))...)
+	builder.SetSecretManagers(managerMock)
+
+	// /////////////////////// WHEN ///////////////////////
+	config := new(provenanceConfig)
+	err = builder.Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]flowconf.Origin{
+		"Name":              {Source: flowconf.DefaultSource},
+		"Port":              {Source: "env(FLOWCONF_PROVENANCE_*)"},
+		"Database.Host":     {Source: "base.toml -> db.json"},
+		"Database.Password": {Source: "base.toml -> db.json", Secrets: []string{"@manager::password"}},
+		"Cache":             {Source: "local.yaml"},
+		"Hosts":             {Source: "base.toml", Secrets: []string{"@manager::host"}},
+		"Labels.team":       {Source: "base.toml"},
+		"Labels.env":        {Source: "local.yaml"},
+		"Tags.owner":        {Source: "local.yaml"},
+		"Servers":           {Source: "base.toml", Secrets: []string{"@manager::server"}},
+	}, builder.Provenance())
+	assert.Equal(t, "very secret", config.Database.Password)
+}
+
+func TestBuilder_Provenance_ofTheLastBuild(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	contents := []string{`{"Name": "first", "Port": 1}`, `{"Name": "second"}`}
+	source := flowconf.NewLazySource("config.json", func(_ context.Context) (flowconf.Format, io.ReadCloser, error) {
+		content := contents[0]
+		contents = contents[1:]
+		return flowconf.Json, io.NopCloser(strings.NewReader(content)), nil
+	})
+
+	builder := flowconf.NewBuilder(source)
+	assert.Empty(t, builder.Provenance())
+	assert.NoError(t, builder.Build(new(provenanceConfig)))
+
+	// /////////////////////// WHEN ///////////////////////
+	err := builder.Build(new(provenanceConfig))
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]flowconf.Origin{
+		"Name": {Source: "config.json"},
+		"Port": {Source: flowconf.DefaultSource},
+	}, builder.Provenance())
+}
@@ -0,0 +1,42 @@
+package flowconf
+
+import (
+	"reflect"
+)
+
+// missingFields returns the path of every field tagged `required:"true"` that
+// is still at its zero value.
+//
+// The fields of a nested struct are checked too, unless it is behind a nil
+// pointer, an optional section that was not set.
+func missingFields(v reflect.Value, prefix string) []string {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct || isLeafType(v.Type()) {
+		return nil
+	}
+
+	var missing []string
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		path := prefix + field.Name
+		if field.Tag.Get("required") == "true" && v.Field(i).IsZero() {
+			missing = append(missing, path)
+			continue
+		}
+
+		missing = append(missing, missingFields(v.Field(i), path+".")...)
+	}
+
+	return missing
+}
@@ -0,0 +1,78 @@
+package flowconf_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type requiredConfig struct {
+	Name     string `required:"true"`
+	Password string `required:"true"`
+	Port     int    `required:"true" default:"8080"`
+	Database struct {
+		Host string `required:"true"`
+		User string `required:"true"`
+	}
+	Cache *struct {
+		Host string `required:"true"`
+	}
+	Labels map[string]string `required:"true"`
+}
+
+func TestBuilder_Build_reportsEveryMissingRequiredField(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		base    = flowconf.NewSource("base.json", flowconf.Json, io.NopCloser(strings.NewReader(`{"Database": {"Host": "db"}}`)))
+		missing = flowconf.NewLazySource("local.json", func(_ context.Context) (flowconf.Format, io.ReadCloser, error) {
+			return "", nil, flowconf.MissingSourceErr
+		})
+		config = new(requiredConfig)
+	)
+
+	// /////////////////////// WHEN ///////////////////////
+	err := flowconf.NewBuilder(base, missing).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	var missingFieldsErr *flowconf.MissingFieldsError
+	if assert.True(t, errors.As(err, &missingFieldsErr)) {
+		assert.Equal(t, []string{"Name", "Password", "Database.User", "Labels"}, missingFieldsErr.Fields)
+		assert.Equal(t, []string{"base.json"}, missingFieldsErr.Sources)
+	}
+	assert.EqualError(t, err, "missing required fields: Name, Password, Database.User, Labels, sources consulted: base.json")
+}
+
+func TestBuilder_Build_requiredFieldsAreCheckedAfterTheSecrets(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		source = flowconf.NewSource("base.json", flowconf.Json, io.NopCloser(strings.NewReader(`{
+			"Name": "app",
+			"Password": "@manager::password",
+			"Database": {"Host": "db", "User": "app"},
+			"Cache": {"Host": "cache"},
+			"Labels": {"team": "core"}
+		}`)))
+		managerMock = new(test.SecretManagerMock)
+		config      = new(requiredConfig)
+	)
+
+	managerMock.On("Prefix").Return("manager")
+	managerMock.On("Secret", mock.Anything, "password").Return("secret", nil)
+
+	builder := flowconf.NewBuilder(source)
+	builder.SetSecretManagers(managerMock)
+
+	// /////////////////////// WHEN ///////////////////////
+	err := builder.Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", config.Password)
+}
@@ -0,0 +1,571 @@
+package flowconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemaDraft is the JSON Schema version of the generated schemas
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// secretReferencePattern matches a reference to a secret, like @gcp::name
+const secretReferencePattern = `^@\w+::[\w/-]+$`
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema is the subset of JSON Schema generated from a configuration
+type schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Const                *string            `json:"const,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              any                `json:"minimum,omitempty"`
+	Maximum              any                `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	Not                  *schema            `json:"not,omitempty"`
+	AnyOf                []*schema          `json:"anyOf,omitempty"`
+}
+
+// GenerateSchema generates the JSON Schema of the configuration files of the
+// format, Json, Toml or Yaml, the keys are named like the decoder of the format
+// names them.
+//
+// The `default`, `required` and `validate` tags are part of the schema, the
+// `description` tag, or else the `usage` tag, describes a field. A string
+// field accepts a secret reference, like "@gcp::name", whatever its rules.
+func GenerateSchema(config any, format Format) ([]byte, error) {
+	if !treeFormats[format] {
+		return nil, fmt.Errorf("no schema for the format: %s", format)
+	}
+
+	t := reflect.TypeOf(config)
+	if t == nil {
+		return nil, IsNilErr
+	}
+
+	s, err := schemaOf(indirectType(t), format, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	s.Schema = schemaDraft
+
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// schemaOf returns the schema of the type, seen holds the structs being
+// generated to stop on recursive types
+func schemaOf(t reflect.Type, format Format, seen map[reflect.Type]bool) (*schema, error) {
+	t = indirectType(t)
+
+	switch {
+	case t == durationType:
+		if format == Json {
+			return &schema{Type: "integer"}, nil
+		}
+		return &schema{Type: []string{"string", "integer"}}, nil
+	case t == timeType:
+		return &schema{Type: "string", Format: "date-time"}, nil
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return &schema{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &schema{Type: "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &schema{Type: "integer", Minimum: 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}, nil
+	case reflect.String:
+		return &schema{Type: "string"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schema{Type: "string"}, nil
+		}
+
+		items, err := schemaOf(t.Elem(), format, seen)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		values, err := schemaOf(t.Elem(), format, seen)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Interface:
+		return &schema{}, nil
+	case reflect.Struct:
+		return structSchema(t, format, seen)
+	}
+
+	return nil, fmt.Errorf("no schema for the type: %s", t)
+}
+
+func structSchema(t reflect.Type, format Format, seen map[reflect.Type]bool) (*schema, error) {
+	if seen[t] {
+		return &schema{Type: "object"}, nil
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	s := &schema{Type: "object", Properties: map[string]*schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := keyName(field, format)
+		if !ok {
+			continue
+		}
+
+		property, err := schemaOf(field.Type, format, seen)
+		if err != nil {
+			return nil, fmt.Errorf("%s, %w", field.Name, err)
+		}
+
+		err = applyTags(property, field, format)
+		if err != nil {
+			return nil, fmt.Errorf("%s, %w", field.Name, err)
+		}
+
+		s.Properties[name] = property
+		if field.Tag.Get("required") == "true" {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return s, nil
+}
+
+// keyName returns the name of the field in a document of the format, like
+// the decoder of the format names it
+func keyName(field reflect.StructField, format Format) (string, bool) {
+	if !field.IsExported() || field.Tag.Get(string(format)) == "-" {
+		return "", false
+	}
+
+	if name := tagName(field, string(format)); name != "" {
+		return name, true
+	}
+	if format == Yaml {
+		return strings.ToLower(field.Name), true
+	}
+
+	return field.Name, true
+}
+
+// applyTags adds the description, the default value and the rules of the
+// field to its schema
+func applyTags(s *schema, field reflect.StructField, format Format) error {
+	s.Description = field.Tag.Get("description")
+	if s.Description == "" {
+		s.Description = field.Tag.Get("usage")
+	}
+
+	if value, ok := field.Tag.Lookup("default"); ok {
+		def, err := schemaValue(field.Type, value, separatorOf(field), format)
+		if err != nil {
+			return fmt.Errorf("invalid default value, %w", err)
+		}
+		s.Default = def
+	}
+
+	rules, err := parseRules(field.Tag.Get("validate"))
+	if err != nil {
+		return fmt.Errorf("invalid validate tag, %w", err)
+	}
+
+	t := indirectType(field.Type)
+	elem := s
+	if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && s.Items != nil {
+		elem = s.Items
+	}
+
+	// the rules of a string are grouped to accept a secret reference instead
+	constrained := &schema{}
+	bounded := s
+	if t.Kind() == reflect.String {
+		bounded = constrained
+	}
+
+	skipsEmpty := true
+	for _, r := range rules {
+		switch r.name {
+		case "min", "max":
+			err = applyBound(bounded, t, r, format)
+			if err != nil {
+				return err
+			}
+			if r.name == "min" {
+				skipsEmpty = false
+			}
+		case "nonempty":
+			one := 1
+			skipsEmpty = false
+			switch t.Kind() {
+			case reflect.String:
+				if constrained.MinLength == nil || *constrained.MinLength < one {
+					constrained.MinLength = &one
+				}
+			case reflect.Slice, reflect.Array:
+				s.MinItems = &one
+			case reflect.Map:
+				s.MinProperties = &one
+			default:
+				zero, err := schemaValue(t, "", defaultSeparator, format)
+				if err == nil {
+					s.Not = &schema{Enum: []any{zero}}
+				}
+			}
+		case "oneof":
+			elemType := t
+			if elem != s {
+				elemType = indirectType(t.Elem())
+			}
+			for _, value := range strings.Fields(r.arg) {
+				v, err := schemaValue(elemType, value, defaultSeparator, format)
+				if err != nil {
+					return fmt.Errorf("invalid oneof value %s, %w", value, err)
+				}
+				constrained.Enum = append(constrained.Enum, v)
+			}
+		case "url":
+			constrained.Format = "uri"
+		case "hostport":
+			constrained.Pattern = `^[^\s]*:[0-9]+$`
+		case "regex":
+			constrained.Pattern = r.arg
+		}
+	}
+
+	if reflect.ValueOf(*constrained).IsZero() {
+		return nil
+	}
+	if elem.Type != "string" {
+		mergeSchema(elem, constrained)
+		return nil
+	}
+
+	// a secret reference is accepted whatever the rules, and so is the empty
+	// string by the rules skipping it
+	elem.AnyOf = []*schema{constrained, {Pattern: secretReferencePattern}}
+	if skipsEmpty || elem != s {
+		empty := ""
+		elem.AnyOf = append(elem.AnyOf, &schema{Const: &empty})
+	}
+
+	return nil
+}
+
+// mergeSchema sets the constraints of src on dst
+func mergeSchema(dst *schema, src *schema) {
+	if src.Enum != nil {
+		dst.Enum = src.Enum
+	}
+	if src.Format != "" {
+		dst.Format = src.Format
+	}
+	if src.Pattern != "" {
+		dst.Pattern = src.Pattern
+	}
+	if src.MinLength != nil {
+		dst.MinLength = src.MinLength
+	}
+	if src.MaxLength != nil {
+		dst.MaxLength = src.MaxLength
+	}
+}
+
+// applyBound sets a min or max rule on the schema, the length of a string,
+// slice or map or the value of a number
+func applyBound(s *schema, t reflect.Type, r rule, format Format) error {
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		var n int
+		if _, err := fmt.Sscan(r.arg, &n); err != nil {
+			return fmt.Errorf("invalid %s value %s, %w", r.name, r.arg, err)
+		}
+
+		bounds := map[reflect.Kind][2]**int{
+			reflect.String: {&s.MinLength, &s.MaxLength},
+			reflect.Slice:  {&s.MinItems, &s.MaxItems},
+			reflect.Array:  {&s.MinItems, &s.MaxItems},
+			reflect.Map:    {&s.MinProperties, &s.MaxProperties},
+		}[t.Kind()]
+		if r.name == "min" {
+			*bounds[0] = &n
+		} else {
+			*bounds[1] = &n
+		}
+		return nil
+	}
+
+	if t == durationType && format != Json {
+		// a duration written as a string can not be bounded
+		return nil
+	}
+
+	bound, err := schemaValue(t, r.arg, defaultSeparator, format)
+	if err != nil {
+		return fmt.Errorf("invalid %s value %s, %w", r.name, r.arg, err)
+	}
+	if r.name == "min" {
+		s.Minimum = bound
+	} else {
+		s.Maximum = bound
+	}
+
+	return nil
+}
+
+// schemaValue converts a value of a tag to the type of the field, as it is
+// written in a document of the format
+func schemaValue(t reflect.Type, str string, sep string, format Format) (any, error) {
+	t = indirectType(t)
+	if t == durationType && format != Json {
+		return str, nil
+	}
+
+	v := reflect.New(t).Elem()
+	if str != "" {
+		if err := setFromString(v, str, sep); err != nil {
+			return nil, err
+		}
+	}
+
+	if t == durationType {
+		return v.Int(), nil
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return str, nil
+	}
+
+	return v.Interface(), nil
+}
+
+// compileSchema compiles a JSON Schema, the formats like uri are asserted
+func compileSchema(content []byte) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+
+	if err := compiler.AddResource(schemaResource, bytes.NewReader(content)); err != nil {
+		return nil, fmt.Errorf("invalid schema, %w", err)
+	}
+
+	s, err := compiler.Compile(schemaResource)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema, %w", err)
+	}
+
+	return s, nil
+}
+
+// schemaResource is the name of the schema given to the Builder
+const schemaResource = "flowconf.schema.json"
+
+// validateDocument validates a TOML, JSON or YAML document, the sources of the
+// other formats are only validated with the merged configuration.
+//
+// The schema names the keys like the JSON decoder, the keys of the document are
+// renamed after the fields of the configuration of type t they set, the
+// violations name the keys as they are written. A source only sets a part of
+// the configuration, the required properties are not checked, nor are the
+// include list and the tombstones
+func validateDocument(s *jsonschema.Schema, doc *document, t reflect.Type) ([]SchemaViolation, error) {
+	tree, err := doc.tree()
+	if err != nil || tree == nil {
+		return nil, err
+	}
+
+	if doc.fromFile {
+		delete(tree, includeKey)
+	}
+
+	keys := map[string]string{}
+	instance := jsonNames(indirectType(t), tree, doc.format, "", "", keys)
+
+	violations, err := validateValue(s, doc.name(), instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []SchemaViolation
+	for _, violation := range violations {
+		if violation.Rule == "required" {
+			continue
+		}
+		if key, ok := keys[violation.Key]; ok {
+			violation.Key = key
+		}
+		kept = append(kept, violation)
+	}
+
+	return kept, nil
+}
+
+// jsonNames renames the keys of the tree of a document of the format after the
+// JSON names of the fields of the struct type t, the keys matching no field are
+// kept, and converts the durations to nanoseconds like the JSON decoder reads
+// them. keys maps the renamed key paths to the key paths of the document
+func jsonNames(t reflect.Type, tree map[string]any, format Format, prefix string, docPrefix string, keys map[string]string) map[string]any {
+	renamed := make(map[string]any, len(tree))
+	for name, value := range tree {
+		docKey := docPrefix + name
+		key := prefix + name
+
+		_, field, ok := treeField(t, name, format)
+		if !ok {
+			keys[key] = docKey
+			renamed[name] = value
+			continue
+		}
+		if jsonName, ok := keyName(field, Json); ok {
+			key = prefix + jsonName
+			name = jsonName
+		}
+		keys[key] = docKey
+
+		ft := indirectType(field.Type)
+		switch {
+		case ft == durationType:
+			value = jsonDuration(value)
+		case ft.Kind() == reflect.Struct && !isLeafType(ft):
+			if nested, ok := value.(map[string]any); ok {
+				value = jsonNames(ft, nested, format, key+".", docKey+".", keys)
+			}
+		case ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array:
+			et := indirectType(ft.Elem())
+			if elements, ok := value.([]any); ok && et == durationType {
+				durations := make([]any, len(elements))
+				for i, element := range elements {
+					durations[i] = jsonDuration(element)
+				}
+				value = durations
+			}
+			if et.Kind() != reflect.Struct || isLeafType(et) {
+				break
+			}
+
+			var list []any
+			switch elements := value.(type) {
+			case []map[string]any:
+				for _, element := range elements {
+					list = append(list, element)
+				}
+			case []any:
+				list = elements
+			}
+
+			renamedList := make([]any, len(list))
+			for i, element := range list {
+				renamedList[i] = element
+				if nested, ok := element.(map[string]any); ok {
+					renamedList[i] = jsonNames(
+						et, nested, format,
+						fmt.Sprintf("%s.%d.", key, i), fmt.Sprintf("%s[%d].", docKey, i),
+						keys,
+					)
+				}
+			}
+			if list != nil {
+				value = renamedList
+			}
+		}
+
+		renamed[name] = value
+	}
+
+	return renamed
+}
+
+// jsonDuration returns the nanoseconds of a duration written as a string, like
+// "5s", the other values are kept
+func jsonDuration(value any) any {
+	str, ok := value.(string)
+	if !ok {
+		return value
+	}
+
+	duration, err := time.ParseDuration(str)
+	if err != nil {
+		return value
+	}
+
+	return int64(duration)
+}
+
+// validateConfig validates the configuration encoded in JSON
+func validateConfig(s *jsonschema.Schema, config any) ([]SchemaViolation, error) {
+	return validateValue(s, "merged configuration", config)
+}
+
+// validateValue validates the value encoded in JSON without its null and
+// tombstone entries, like a nil pointer, it returns the violated rules
+func validateValue(s *jsonschema.Schema, source string, value any) ([]SchemaViolation, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var instance any
+	if err = decoder.Decode(&instance); err != nil {
+		return nil, err
+	}
+
+	err = s.Validate(stripTombstones(instance))
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+
+	violations := schemaViolations(source, validationErr)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Key < violations[j].Key
+	})
+
+	return violations, nil
+}
+
+// schemaViolations returns the rules that are violated, the errors of the
+// branches of anyOf and oneOf are reported as one
+func schemaViolations(source string, err *jsonschema.ValidationError) []SchemaViolation {
+	rule := path.Base(err.KeywordLocation)
+	if len(err.Causes) == 0 || rule == "anyOf" || rule == "oneOf" {
+		key := strings.ReplaceAll(strings.TrimPrefix(err.InstanceLocation, "/"), "/", ".")
+		return []SchemaViolation{{Source: source, Key: key, Rule: rule, Message: err.Message}}
+	}
+
+	var violations []SchemaViolation
+	for _, cause := range err.Causes {
+		violations = append(violations, schemaViolations(source, cause)...)
+	}
+
+	return violations
+}
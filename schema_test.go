@@ -0,0 +1,267 @@
+package flowconf_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaConfig struct {
+	Port     uint16        `default:"8080" validate:"min=1,max=65535" description:"the port to listen on"`
+	Mode     string        `json:"mode" toml:"Mode" required:"true" validate:"oneof=dev prod" usage:"the mode"`
+	Password string        `validate:"nonempty"`
+	Timeout  time.Duration `default:"5s"`
+	Origins  []string      `validate:"url"`
+	Database *struct {
+		Host string `required:"true"`
+	}
+	Labels   map[string]int
+	Internal string `json:"-"`
+}
+
+func TestGenerateSchema(t *testing.T) {
+	secretReference := `{"pattern": "^@\\w+::[\\w/-]+$"}`
+
+	tests := []struct {
+		name   string
+		format flowconf.Format
+		want   string
+	}{
+		{
+			name:   "json",
+			format: flowconf.Json,
+			want: `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"type": "object",
+				"properties": {
+					"Port": {"type": "integer", "description": "the port to listen on", "default": 8080, "minimum": 1, "maximum": 65535},
+					"mode": {"type": "string", "description": "the mode", "anyOf": [{"enum": ["dev", "prod"]}, ` + secretReference + `, {"const": ""}]},
+					"Password": {"type": "string", "anyOf": [{"minLength": 1}, ` + secretReference + `]},
+					"Timeout": {"type": "integer", "default": 5000000000},
+					"Origins": {"type": "array", "items": {"type": "string", "anyOf": [{"format": "uri"}, ` + secretReference + `, {"const": ""}]}},
+					"Database": {"type": "object", "properties": {"Host": {"type": "string"}}, "required": ["Host"]},
+					"Labels": {"type": "object", "additionalProperties": {"type": "integer"}}
+				},
+				"required": ["mode"]
+			}`,
+		},
+		{
+			name:   "yaml",
+			format: flowconf.Yaml,
+			want: `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"type": "object",
+				"properties": {
+					"port": {"type": "integer", "description": "the port to listen on", "default": 8080, "minimum": 1, "maximum": 65535},
+					"mode": {"type": "string", "description": "the mode", "anyOf": [{"enum": ["dev", "prod"]}, ` + secretReference + `, {"const": ""}]},
+					"password": {"type": "string", "anyOf": [{"minLength": 1}, ` + secretReference + `]},
+					"timeout": {"type": ["string", "integer"], "default": "5s"},
+					"origins": {"type": "array", "items": {"type": "string", "anyOf": [{"format": "uri"}, ` + secretReference + `, {"const": ""}]}},
+					"database": {"type": "object", "properties": {"host": {"type": "string"}}, "required": ["host"]},
+					"labels": {"type": "object", "additionalProperties": {"type": "integer"}},
+					"internal": {"type": "string"}
+				},
+				"required": ["mode"]
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// /////////////////////// WHEN ///////////////////////
+			got, err := flowconf.GenerateSchema(new(schemaConfig), tt.format)
+
+			// /////////////////////// THEN ///////////////////////
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestGenerateSchema_tomlUsesTheTomlTags(t *testing.T) {
+	// /////////////////////// WHEN ///////////////////////
+	got, err := flowconf.GenerateSchema(new(schemaConfig), flowconf.Toml)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Contains(t, string(got), `"Mode": {`)
+	assert.Contains(t, string(got), `"Internal": {`)
+	assert.Contains(t, string(got), `"default": "5s"`)
+}
+
+func TestGenerateSchema_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config any
+		format flowconf.Format
+		want   string
+	}{
+		{
+			name:   "flat format",
+			config: new(schemaConfig),
+			format: flowconf.Dotenv,
+			want:   "no schema for the format: dotenv",
+		},
+		{
+			name: "invalid default",
+			config: &struct {
+				Port int `default:"http"`
+			}{},
+			format: flowconf.Json,
+			want:   `Port, invalid default value, strconv.ParseInt: parsing "http": invalid syntax`,
+		},
+		{
+			name: "unsupported type",
+			config: &struct {
+				Done chan bool
+			}{},
+			format: flowconf.Json,
+			want:   "Done, no schema for the type: chan bool",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// /////////////////////// WHEN ///////////////////////
+			_, err := flowconf.GenerateSchema(tt.config, tt.format)
+
+			// /////////////////////// THEN ///////////////////////
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+type schemaValidatedConfig struct {
+	Port     int           `json:"port" toml:"port" yaml:"port" validate:"max=65535"`
+	Mode     string        `json:"mode" toml:"mode" yaml:"mode" required:"true" validate:"oneof=dev prod"`
+	Password string        `json:"password" toml:"password" yaml:"password"`
+	Timeout  time.Duration `json:"timeout" toml:"timeout" yaml:"timeout"`
+	Since    time.Time     `json:"since" toml:"since" yaml:"since"`
+	Cache    *struct {
+		Size int `json:"size" toml:"max_size" yaml:"maxSize" validate:"max=1024"`
+	} `json:"cache" toml:"cache_settings" yaml:"cacheSettings"`
+	Servers []struct {
+		Port int `json:"port" toml:"listen_port" yaml:"listenPort" validate:"max=65535"`
+	} `json:"servers" toml:"servers" yaml:"servers"`
+	Database struct {
+		Host string `json:"host" toml:"host" yaml:"host"`
+	} `json:"database" toml:"database" yaml:"database"`
+}
+
+func TestBuilder_Build_validatesTheSourcesAgainstTheSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		paths []string
+		env   map[string]string
+		want  []flowconf.SchemaViolation
+	}{
+		{
+			name: "valid overlays",
+			files: fstest.MapFS{
+				"base.toml":  {Data: []byte("include = [\"db.yaml\"]\nmode = \"dev\"\nport = 80")},
+				"db.yaml":    {Data: []byte("database:\n  host: db")},
+				"local.json": {Data: []byte(`{"password": "@manager::password", "port": "@unset"}`)},
+			},
+			paths: []string{"base.toml", "local.json"},
+		},
+		{
+			name: "invalid sources",
+			files: fstest.MapFS{
+				"base.toml":  {Data: []byte("port = \"eighty\"\n[database]\nhost = 1")},
+				"local.json": {Data: []byte(`{"mode": "test", "port": 70000}`)},
+			},
+			paths: []string{"base.toml", "local.json"},
+			want: []flowconf.SchemaViolation{
+				{Source: "base.toml", Key: "database.host", Rule: "type", Message: "expected string, but got number"},
+				{Source: "base.toml", Key: "port", Rule: "type", Message: "expected integer, but got string"},
+				{Source: "local.json", Key: "mode", Rule: "anyOf", Message: "anyOf failed"},
+				{Source: "local.json", Key: "port", Rule: "maximum", Message: "must be <= 65535 but found 70000"},
+			},
+		},
+		{
+			name: "keys named by the format",
+			files: fstest.MapFS{
+				"base.toml":  {Data: []byte("mode = \"dev\"\ntimeout = \"5s\"\nsince = 2024-01-02\n[cache_settings]\nmax_size = 64\n[[servers]]\nlisten_port = 80")},
+				"local.yaml": {Data: []byte("timeout: 1m30s\nsince: 2024-01-02T15:04:05Z\ncacheSettings:\n  maxSize: 128\nservers:\n  - listenPort: 8080")},
+			},
+			paths: []string{"base.toml", "local.yaml"},
+		},
+		{
+			name: "violations named by the format",
+			files: fstest.MapFS{
+				"base.toml":  {Data: []byte("mode = \"dev\"\n[cache_settings]\nmax_size = 2048\n[[servers]]\nlisten_port = 80")},
+				"local.yaml": {Data: []byte("timeout: soon\ncacheSettings:\n  maxSize: 64\nservers:\n  - listenPort: 8080\n  - listenPort: 70000")},
+			},
+			paths: []string{"base.toml", "local.yaml"},
+			want: []flowconf.SchemaViolation{
+				{Source: "base.toml", Key: "cache_settings.max_size", Rule: "maximum", Message: "must be <= 1024 but found 2048"},
+				{Source: "local.yaml", Key: "servers[1].listenPort", Rule: "maximum", Message: "must be <= 65535 but found 70000"},
+				{Source: "local.yaml", Key: "timeout", Rule: "type", Message: "expected integer, but got string"},
+			},
+		},
+		{
+			name: "required properties are checked in the merged configuration only",
+			files: fstest.MapFS{
+				"base.json":  {Data: []byte(`{"port": 80}`)},
+				"local.json": {Data: []byte(`{"mode": "dev"}`)},
+			},
+			paths: []string{"base.json", "local.json"},
+		},
+		{
+			name: "merged configuration",
+			files: fstest.MapFS{
+				"base.toml": {Data: []byte("mode = \"dev\"\nport = 80")},
+			},
+			paths: []string{"base.toml"},
+			env:   map[string]string{"FLOWCONF_SCHEMA_PORT": "70000"},
+			want: []flowconf.SchemaViolation{
+				{Source: "merged configuration", Key: "port", Rule: "maximum", Message: "must be <= 65535 but found 70000"},
+			},
+		},
+	}
+
+	schema, err := flowconf.GenerateSchema(new(schemaValidatedConfig), flowconf.Json)
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// /////////////////////// GIVEN ///////////////////////
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			sources, err := flowconf.NewSourcesFromFS(tt.files, tt.paths...)
+			assert.NoError(t, err)
+
+			builder := flowconf.NewBuilder(append(sources, flowconf.NewSourceFromEnv("FLOWCONF_SCHEMA"))...)
+			assert.NoError(t, builder.SetSchema(schema))
+
+			// /////////////////////// WHEN ///////////////////////
+			err = builder.Build(new(schemaValidatedConfig))
+
+			// /////////////////////// THEN ///////////////////////
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var schemaErr *flowconf.SchemaError
+			if assert.True(t, errors.As(err, &schemaErr)) {
+				assert.Equal(t, tt.want, schemaErr.Violations)
+			}
+		})
+	}
+}
+
+func TestBuilder_SetSchema_invalidSchema(t *testing.T) {
+	// /////////////////////// WHEN ///////////////////////
+	err := flowconf.NewBuilder().SetSchema([]byte(`{"type": 1}`))
+
+	// /////////////////////// THEN ///////////////////////
+	assert.ErrorContains(t, err, "invalid schema")
+}
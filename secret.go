@@ -0,0 +1,173 @@
+package flowconf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+)
+
+// Secret is a string that is never printed, encoded or logged, its value is
+// read with Reveal. A secret reference, like "@gcp::name", decoded into a
+// Secret is resolved by the Builder like the references of the strings.
+//
+//	type Configuration struct {
+//		Password flowconf.Secret
+//	}
+type Secret struct {
+	value string
+}
+
+var secretType = reflect.TypeOf(Secret{})
+
+// NewSecret returns a Secret holding the value
+func NewSecret(value string) Secret {
+	return Secret{value: value}
+}
+
+// Reveal returns the value of the secret
+func (secret Secret) Reveal() string {
+	return secret.value
+}
+
+func (secret Secret) String() string {
+	return RedactionMarker
+}
+
+func (secret Secret) GoString() string {
+	return "flowconf.Secret(" + RedactionMarker + ")"
+}
+
+// Format redacts the secret whatever the verb, %#v prints the GoString
+func (secret Secret) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		_, _ = io.WriteString(f, secret.GoString())
+		return
+	}
+
+	_, _ = io.WriteString(f, RedactionMarker)
+}
+
+func (secret Secret) LogValue() slog.Value {
+	return slog.StringValue(RedactionMarker)
+}
+
+func (secret Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + RedactionMarker + `"`), nil
+}
+
+func (secret Secret) MarshalText() ([]byte, error) {
+	return []byte(RedactionMarker), nil
+}
+
+// UnmarshalText sets the value of the secret, it is used by every format
+func (secret *Secret) UnmarshalText(text []byte) error {
+	secret.value = string(text)
+	return nil
+}
+
+// secretValue is a string or a Secret of the configuration holding a
+// reference to a secret, and the function setting its resolved value
+type secretValue struct {
+	value string
+	set   func(value string)
+}
+
+// findSecretValues walks the configuration and returns the strings and the
+// Secret values holding a reference. The values of a map or an interface are
+// not addressable, they are set by replacing the entry with a copy, commit
+// sets the copies up to the configuration
+func findSecretValues(v reflect.Value, commit func()) []secretValue {
+	if v.Type() == secretType {
+		secret := v.Interface().(Secret)
+		if !managerReg.MatchString(secret.value) {
+			return nil
+		}
+
+		return []secretValue{{
+			value: secret.value,
+			set: func(value string) {
+				v.Set(reflect.ValueOf(NewSecret(value)))
+				commit()
+			},
+		}}
+	}
+
+	var values []secretValue
+	switch v.Kind() {
+	case reflect.String:
+		if managerReg.MatchString(v.String()) {
+			values = append(values, secretValue{
+				value: v.String(),
+				set: func(value string) {
+					v.SetString(value)
+					commit()
+				},
+			})
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			values = findSecretValues(v.Elem(), func() {})
+		}
+	case reflect.Interface:
+		if !v.IsNil() && v.CanSet() {
+			elem := reflect.New(v.Elem().Type()).Elem()
+			elem.Set(v.Elem())
+			values = findSecretValues(elem, func() {
+				v.Set(elem)
+				commit()
+			})
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				values = append(values, findSecretValues(v.Field(i), commit)...)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, findSecretValues(v.Index(i), commit)...)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			values = append(values, findSecretValues(elem, func() {
+				v.SetMapIndex(key, elem)
+				commit()
+			})...)
+		}
+	}
+
+	return values
+}
+
+// resolveReferences replaces the references to a secret of the string by the
+// secrets
+func resolveReferences(ctx context.Context, str string, managers []SecretManager) (string, error) {
+	var err error
+	resolved := managerReg.ReplaceAllStringFunc(str, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+
+		match := managerReg.FindStringSubmatch(ref)
+		var manager SecretManager
+		manager, err = getManagerForPrefix(match[1], managers)
+		if err != nil {
+			return ref
+		}
+
+		var secret string
+		secret, err = manager.Secret(ctx, match[2])
+		return secret
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resolved, nil
+}
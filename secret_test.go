@@ -0,0 +1,137 @@
+package flowconf_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+	"testing/fstest"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type secretConfig struct {
+	Password flowconf.Secret
+	Token    *flowconf.Secret
+	Plain    flowconf.Secret
+	Keys     []flowconf.Secret
+	Tenants  map[string]flowconf.Secret
+}
+
+func TestSecret_isRedacted(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	config := secretConfig{Password: flowconf.NewSecret("very secret")}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	// /////////////////////// WHEN ///////////////////////
+	logger.Info("config", "password", config.Password)
+	encoded, err := json.Marshal(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	for _, printed := range []string{
+		fmt.Sprint(config.Password),
+		fmt.Sprintf("%s %q %d %x", config.Password, config.Password, config.Password, config.Password),
+		fmt.Sprintf("%v", config),
+		fmt.Sprintf("%+v", config),
+		fmt.Sprintf("%#v", config),
+		string(encoded),
+		logs.String(),
+	} {
+		assert.NotContains(t, printed, "very secret")
+		assert.Contains(t, printed, flowconf.RedactionMarker)
+	}
+	assert.Equal(t, "flowconf.Secret([REDACTED])", fmt.Sprintf("%#v", config.Password))
+	assert.Equal(t, "very secret", config.Password.Reveal())
+}
+
+func TestBuilder_Build_resolvesTheSecretValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "json",
+			file: "config.json",
+			content: `{
+				"Password": "@manager::password",
+				"Token": "@manager::token",
+				"Plain": "not a reference",
+				"Keys": ["@manager::password", "key"],
+				"Tenants": {"acme": "@manager::token"}
+			}`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `
+Password = "@manager::password"
+Token = "@manager::token"
+Plain = "not a reference"
+Keys = [ "@manager::password", "key" ]
+Tenants = { acme = "@manager::token" }
+`,
+		},
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `
+password: "@manager::password"
+token: "@manager::token"
+plain: not a reference
+keys: ["@manager::password", key]
+tenants: {acme: "@manager::token"}
+`,
+		},
+		{
+			name: "dotenv",
+			file: "config.env",
+			content: `
+PASSWORD=@manager::password
+TOKEN=@manager::token
+PLAIN="not a reference"
+KEYS=@manager::password,key
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// /////////////////////// GIVEN ///////////////////////
+			sources, err := flowconf.NewSourcesFromFS(fstest.MapFS{tt.file: {Data: []byte(tt.content)}}, tt.file)
+			assert.NoError(t, err)
+
+			managerMock := new(test.SecretManagerMock)
+			managerMock.On("Prefix").Return("manager")
+			managerMock.On("Secret", mock.Anything, "password").Return("very secret", nil)
+			managerMock.On("Secret", mock.Anything, "token").Return("token secret", nil)
+
+			builder := flowconf.NewBuilder(sources...)
+			builder.SetSecretManagers(managerMock)
+
+			// /////////////////////// WHEN ///////////////////////
+			config := new(secretConfig)
+			err = builder.Build(config)
+
+			// /////////////////////// THEN ///////////////////////
+			assert.NoError(t, err)
+			assert.Equal(t, "very secret", config.Password.Reveal())
+			if assert.NotNil(t, config.Token) {
+				assert.Equal(t, "token secret", config.Token.Reveal())
+			}
+			assert.Equal(t, "not a reference", config.Plain.Reveal())
+			assert.Equal(t, []flowconf.Secret{flowconf.NewSecret("very secret"), flowconf.NewSecret("key")}, config.Keys)
+			if tt.name != "dotenv" {
+				assert.Equal(t, map[string]flowconf.Secret{"acme": flowconf.NewSecret("token secret")}, config.Tenants)
+			}
+			assert.Equal(t, []string{"@manager::password"}, builder.Provenance()["Password"].Secrets)
+		})
+	}
+}
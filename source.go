@@ -1,11 +1,15 @@
 package flowconf
 
 import (
+	"bytes"
+	"context"
 	"embed"
-	"fmt"
+	"errors"
 	"io"
+	"io/fs"
 	"os"
 	"strings"
+	"sync"
 )
 
 // Format represents a string type that specifies a format.
@@ -15,13 +19,53 @@ const (
 	unknown Format = "unknown"
 	Toml    Format = "toml"
 	Json    Format = "json"
+	Yaml    Format = "yaml"
 )
 
-// StaticSource represent a config input
+// Source is a configuration input of the Builder
+type Source interface {
+	// Name identifies the source in errors
+	Name() string
+	// Load is called by the Builder when it needs the content of the source,
+	// the Builder closes the reader once it is decoded.
+	// An optional source that does not exist returns an error wrapping
+	// MissingSourceErr to be skipped
+	Load(ctx context.Context) (Format, io.ReadCloser, error)
+}
+
+// LoadFunc loads the content of a source
+type LoadFunc func(ctx context.Context) (Format, io.ReadCloser, error)
+
+type lazySource struct {
+	name string
+	load LoadFunc
+}
+
+// NewLazySource creates a source calling load every time the Builder needs it,
+// making it possible to open a file at build time, fetch the content over the
+// network or generate it
+func NewLazySource(name string, load LoadFunc) Source {
+	return &lazySource{name: name, load: load}
+}
+
+func (source *lazySource) Name() string {
+	return source.name
+}
+
+func (source *lazySource) Load(ctx context.Context) (Format, io.ReadCloser, error) {
+	return source.load(ctx)
+}
+
+// StaticSource represent a config input.
+// The reader is consumed on the first load, its content is kept in memory for
+// the next ones
 type StaticSource struct {
 	name   string
 	format Format
-	reader io.ReadCloser
+
+	mu      sync.Mutex
+	reader  io.ReadCloser
+	content []byte
 }
 
 func NewSource(name string, format Format, reader io.ReadCloser) *StaticSource {
@@ -32,39 +76,99 @@ func NewSource(name string, format Format, reader io.ReadCloser) *StaticSource {
 	}
 }
 
-func NewSourcesFromFilepaths(filepaths ...string) ([]*StaticSource, error) {
+func (source *StaticSource) Name() string {
+	return source.name
+}
+
+func (source *StaticSource) Load(_ context.Context) (Format, io.ReadCloser, error) {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	if source.reader != nil {
+		content, err := io.ReadAll(source.reader)
+		if err != nil {
+			return "", nil, err
+		}
+
+		err = source.reader.Close()
+		if err != nil {
+			return "", nil, err
+		}
+
+		source.reader = nil
+		source.content = content
+	}
+
+	return source.format, io.NopCloser(bytes.NewReader(source.content)), nil
+}
+
+func NewSourcesFromFilepaths(filepaths ...string) ([]Source, error) {
 	return LoadSourcesWithOpener(osOpener(os.Open), filepaths...)
 }
 
-func NewSourcesFromEmbeddedFileSystem(fs embed.FS, filepaths ...string) ([]*StaticSource, error) {
-	return LoadSourcesWithOpener(embeddedOpener(fs.Open), filepaths...)
+func NewSourcesFromEmbeddedFileSystem(fs embed.FS, filepaths ...string) ([]Source, error) {
+	return NewSourcesFromFS(fs, filepaths...)
 }
 
-func LoadSourcesWithOpener(opener Opener, filepaths ...string) ([]*StaticSource, error) {
-	var sources []*StaticSource
-	for _, filepath := range filepaths {
-		f, err := opener.Open(filepath)
-		if err != nil {
-			return nil, err
+// NewSourcesFromFS loads the sources from any file system, like os.DirFS or
+// fstest.MapFS. A path can be a glob pattern or a directory, expanded like
+// with NewSourcesFromGlob, or a file that is optional if it ends with a
+// question mark.
+func NewSourcesFromFS(fsys fs.FS, paths ...string) ([]Source, error) {
+	var filepaths []string
+	for _, p := range paths {
+		if !isPattern(fsys, p) {
+			filepaths = append(filepaths, p)
+			continue
 		}
-		sources = append(sources, NewSource(filepath, detectFormat(filepath), f))
 
+		matches, err := expandPattern(fsLister{fsys}, p)
 		if err != nil {
-			return nil, fmt.Errorf("failed to close opener, %w", err)
+			return nil, err
 		}
+		filepaths = append(filepaths, matches...)
 	}
 
-	return sources, nil
+	return LoadSourcesWithOpener(fsOpener(fsys.Open), filepaths...)
 }
 
-func detectFormat(str string) Format {
-	if strings.HasSuffix(str, ".toml") {
-		return Toml
+// isPattern reports if the path is a glob pattern or a directory, the
+// question mark of an optional file is not a pattern
+func isPattern(fsys fs.FS, p string) bool {
+	if strings.HasSuffix(p, "?") {
+		return strings.ContainsAny(strings.TrimSuffix(p, "?"), `*?[\`)
 	}
+	if strings.ContainsAny(p, `*?[\`) {
+		return true
+	}
+
+	info, err := fs.Stat(fsys, p)
+	return err == nil && info.IsDir()
+}
+
+// LoadSourcesWithOpener opens the files and returns them as sources in the same order.
+//
+// A filepath ending with a question mark is optional, "config-local.json?" does
+// not fail the loading or the build if the file does not exist, the Builder
+// skips it. An invalid file still fails the build.
+func LoadSourcesWithOpener(opener Opener, filepaths ...string) ([]Source, error) {
+	var sources []Source
+	for _, filepath := range filepaths {
+		filepath, optional := strings.CutSuffix(filepath, "?")
 
-	if strings.HasSuffix(str, ".json") {
-		return Json
+		f, err := opener.Open(filepath)
+		if err != nil {
+			if !optional || !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			// it will be looked for again when the source is loaded
+			f = nil
+		}
+
+		source := newFileSource(opener, filepath, f)
+		source.optional = optional
+		sources = append(sources, source)
 	}
 
-	return unknown
+	return sources, nil
 }
@@ -2,6 +2,7 @@ package flowconf
 
 import (
 	"bytes"
+	"context"
 	"github.com/SamuelTissot/flowconf/test"
 	"github.com/stretchr/testify/assert"
 	"io"
@@ -16,9 +17,9 @@ func TestNewSourceFromFilepaths_returnTwoSourceForTwoInputFiles(t *testing.T) {
 		readerOne  = io.NopCloser(bytes.NewReader([]byte("content one")))
 		readerTwo  = io.NopCloser(bytes.NewReader([]byte("content two")))
 		openerMock = new(test.FileOpenerMock)
-		want       = []*StaticSource{
-			NewSource(fileOne, Toml, readerOne),
-			NewSource(fileTwo, Json, readerTwo),
+		want       = []Source{
+			newFileSource(openerMock, fileOne, readerOne),
+			newFileSource(openerMock, fileTwo, readerTwo),
 		}
 	)
 
@@ -33,3 +34,31 @@ func TestNewSourceFromFilepaths_returnTwoSourceForTwoInputFiles(t *testing.T) {
 	assert.EqualValues(t, want, got)
 
 }
+
+func TestFileSource_Load_reopensTheFileAfterTheFirstLoad(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		file       = "/some/path/conf.toml"
+		opened     = io.NopCloser(bytes.NewReader([]byte("first")))
+		reopened   = io.NopCloser(bytes.NewReader([]byte("second")))
+		openerMock = new(test.FileOpenerMock)
+	)
+
+	openerMock.On("Open", file).Return(opened, nil).Once()
+	openerMock.On("Open", file).Return(reopened, nil).Once()
+
+	sources, err := LoadSourcesWithOpener(openerMock, file)
+	assert.NoError(t, err)
+
+	// /////////////////////// WHEN ///////////////////////
+	_, first, errFirst := sources[0].Load(context.Background())
+	format, second, errSecond := sources[0].Load(context.Background())
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, errFirst)
+	assert.NoError(t, errSecond)
+	assert.Equal(t, Toml, format)
+	assert.Equal(t, opened, first)
+	assert.Equal(t, reopened, second)
+	openerMock.AssertExpectations(t)
+}
@@ -0,0 +1,137 @@
+package flowconf_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/SamuelTissot/flowconf/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type strictConfig struct {
+	Name     string
+	Database struct {
+		Host string `json:"host" toml:"host" yaml:"host"`
+	}
+	Labels map[string]string
+}
+
+func TestBuilder_Build_strictReportsUnknownKeys(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	t.Setenv("FLOWCONF_TEST_NAME", "app")
+	t.Setenv("FLOWCONF_TEST_DATABASE_HOTS", "typo")
+
+	fsys := fstest.MapFS{
+		"base.toml": {Data: []byte(`
+include = [ "db.json" ]
+name = "case does not matter"
+Nmae = "typo"
+Labels = { any = "key" }
+[Database]
+host = "db"
+port = 5432
+`)},
+		"db.json":    {Data: []byte(`{"Database": {"Host": "db", "hots": "typo"}, "Extra": {"a": 1}}`)},
+		"local.yaml": {Data: []byte("name: app\nName: typo\ndatabase:\n  host: db")},
+		"local.env":  {Data: []byte("NAME=app\nDATABASE_PORT=5432")},
+	}
+
+	sources, err := flowconf.NewSourcesFromFS(fsys, "base.toml", "local.yaml", "local.env")
+	assert.NoError(t, err)
+	inline := flowconf.NewSource("inline.json", flowconf.Json, io.NopCloser(strings.NewReader(`{"include": []}`)))
+
+	var warned []string
+	builder := flowconf.NewBuilder(append(sources, inline, flowconf.NewSourceFromEnv("FLOWCONF_TEST"))...)
+	builder.SetStrict(true)
+	builder.SetUnknownKeyHook(func(source string, key string) {
+		warned = append(warned, source+": "+key)
+	})
+
+	// /////////////////////// WHEN ///////////////////////
+	err = builder.Build(new(strictConfig))
+
+	// /////////////////////// THEN ///////////////////////
+	want := []flowconf.UnknownKey{
+		{Source: "base.toml -> db.json", Key: "Database.hots"},
+		{Source: "base.toml -> db.json", Key: "Extra"},
+		{Source: "base.toml", Key: "Database.port"},
+		{Source: "base.toml", Key: "Nmae"},
+		{Source: "local.yaml", Key: "Name"},
+		{Source: "local.env", Key: "DATABASE_PORT"},
+		{Source: "inline.json", Key: "include"},
+		{Source: "env(FLOWCONF_TEST_*)", Key: "DATABASE_HOTS"},
+	}
+
+	var unknownKeysErr *flowconf.UnknownKeysError
+	if assert.True(t, errors.As(err, &unknownKeysErr)) {
+		assert.Equal(t, want, unknownKeysErr.Keys)
+	}
+	assert.Len(t, warned, len(want))
+	assert.Contains(t, warned, "base.toml: Nmae")
+}
+
+func TestBuilder_Build_unknownKeysAreIgnoredByDefault(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var (
+		source = flowconf.NewSource("base.json", flowconf.Json, io.NopCloser(strings.NewReader(`{"MeaningOfLive": 42}`)))
+		warned []string
+	)
+
+	builder := flowconf.NewBuilder(source)
+	builder.SetUnknownKeyHook(func(source string, key string) {
+		warned = append(warned, source+": "+key)
+	})
+
+	// /////////////////////// WHEN ///////////////////////
+	err := builder.Build(new(test.Config))
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"base.json: MeaningOfLive"}, warned)
+}
+
+func TestBuilder_Build_strictReportsUnknownKeysOfListElements(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	type server struct {
+		Host string
+		Tags []struct{ Name string }
+	}
+	var config struct {
+		Servers []server
+	}
+
+	fsys := fstest.MapFS{
+		"base.toml": {Data: []byte(`
+[[Servers]]
+Host = "a"
+[[Servers]]
+Hots = "typo"
+[[Servers.Tags]]
+Nmae = "typo"
+`)},
+		"local.json": {Data: []byte(`{"Servers": [{"Host": "a"}, {"Host": "b", "Port": 80}]}`)},
+	}
+
+	sources, err := flowconf.NewSourcesFromFS(fsys, "base.toml", "local.json")
+	assert.NoError(t, err)
+
+	builder := flowconf.NewBuilder(sources...)
+	builder.SetStrict(true)
+
+	// /////////////////////// WHEN ///////////////////////
+	err = builder.Build(&config)
+
+	// /////////////////////// THEN ///////////////////////
+	var unknownKeysErr *flowconf.UnknownKeysError
+	if assert.True(t, errors.As(err, &unknownKeysErr)) {
+		assert.Equal(t, []flowconf.UnknownKey{
+			{Source: "base.toml", Key: "Servers[1].Hots"},
+			{Source: "base.toml", Key: "Servers[1].Tags[0].Nmae"},
+			{Source: "local.json", Key: "Servers[1].Port"},
+		}, unknownKeysErr.Keys)
+	}
+}
@@ -9,5 +9,5 @@ type Config struct {
 	Perfection      []int
 	BackToTheFuture time.Time
 	Secret          string
-	Tag             string `json:"TagValue" toml:"TagValue"`
+	Tag             string `json:"TagValue" toml:"TagValue" yaml:"TagValue"`
 }
@@ -0,0 +1,59 @@
+package flowconf
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type swappedConfig struct {
+	Name  string
+	Ports []int
+}
+
+// swapJSON registers a JSON decoder that only decodes into a *swappedConfig,
+// the built-in decoder is registered back when the test ends
+func swapJSON(t *testing.T) {
+	RegisterFormat(Json, []string{".json"}, func(config any, r io.Reader) error {
+		return json.NewDecoder(r).Decode(config.(*swappedConfig))
+	})
+	t.Cleanup(func() {
+		registerTreeFormat(Json, []string{".json"}, parseJSON)
+	})
+}
+
+func TestBuilder_Build_swappedDecoderOnlyDecodesIntoTheConfiguration(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	swapJSON(t)
+
+	fsys := fstest.MapFS{"config.json": {Data: []byte(`{"Name": "app", "include": ["other.json"]}`)}}
+	sources, err := NewSourcesFromFS(fsys, "config.json")
+	assert.NoError(t, err)
+
+	// /////////////////////// WHEN ///////////////////////
+	config := new(swappedConfig)
+	err = NewBuilder(sources...).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, "app", config.Name)
+}
+
+func TestBuilder_Build_swappedDecoderDecodesTheTombstones(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	swapJSON(t)
+
+	source := NewSource("config.json", Json, io.NopCloser(strings.NewReader(`{"Name": null, "Ports": [1]}`)))
+
+	// /////////////////////// WHEN ///////////////////////
+	config := &swappedConfig{Name: "base"}
+	err := NewBuilder(source).Build(config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.NoError(t, err)
+	assert.Equal(t, &swappedConfig{Name: "base", Ports: []int{1}}, config)
+}
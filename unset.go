@@ -0,0 +1,191 @@
+package flowconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// UnsetMarker is the value resetting a field to its zero value. In a JSON or
+// YAML document a null value does the same.
+//
+//	{
+//		"Database": { "Password": "@unset" },
+//		"Cache": null
+//	}
+//
+// The entry of a map is deleted instead.
+const UnsetMarker = "@unset"
+
+func isTombstone(value any) bool {
+	return value == nil || value == UnsetMarker
+}
+
+// apply decodes the document into config, following the merge strategies and
+// resetting the fields with a tombstone value. The keys are the keys of the
+// document for the configuration
+func (doc *document) apply(config any, keys []docKey) error {
+	var err error
+	var tombstones [][]string
+	for _, key := range keys {
+		if key.path != nil && isTombstone(key.value) {
+			tombstones = append(tombstones, key.path)
+		}
+	}
+
+	// the decoders of the flat formats skip the tombstones on their own, the
+	// documents without a tree have no keys and so no tombstones
+	decoded := doc
+	if len(tombstones) > 0 && doc.hasTree() {
+		decoded, err = doc.withoutTombstones()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = decodeDocument(config, decoded)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range tombstones {
+		unsetPath(reflect.ValueOf(config), path)
+	}
+
+	return nil
+}
+
+// withoutTombstones returns a copy of the document without the map entries
+// having a tombstone value, so the marker is not decoded into a field of
+// another type than string. The document is re-encoded with the built-in
+// encoder of its format, it is returned as is if its decoder was swapped
+func (doc *document) withoutTombstones() (*document, error) {
+	if !doc.hasTree() {
+		return doc, nil
+	}
+
+	var buf bytes.Buffer
+	switch doc.format {
+	case Json:
+		decoder := json.NewDecoder(bytes.NewReader(doc.content))
+		decoder.UseNumber()
+
+		var tree any
+		if err := decoder.Decode(&tree); err != nil {
+			return nil, err
+		}
+		if err := json.NewEncoder(&buf).Encode(stripTombstones(tree)); err != nil {
+			return nil, err
+		}
+	case Toml:
+		tree, err := doc.tree()
+		if err != nil {
+			return nil, err
+		}
+		if err := toml.NewEncoder(&buf).Encode(stripTombstones(tree)); err != nil {
+			return nil, err
+		}
+	case Yaml:
+		decoder := yaml.NewDecoder(bytes.NewReader(doc.content))
+		encoder := yaml.NewEncoder(&buf)
+		for {
+			var node yaml.Node
+			err := decoder.Decode(&node)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			stripYAMLTombstones(&node)
+			if err := encoder.Encode(&node); err != nil {
+				return nil, err
+			}
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return doc, nil
+	}
+
+	return &document{chain: doc.chain, format: doc.format, content: buf.Bytes()}, nil
+}
+
+// stripTombstones removes the entries with a tombstone value from the maps,
+// the elements of the arrays are kept
+func stripTombstones(tree any) any {
+	m, ok := tree.(map[string]any)
+	if !ok {
+		return tree
+	}
+
+	for key, value := range m {
+		if isTombstone(value) {
+			delete(m, key)
+			continue
+		}
+		m[key] = stripTombstones(value)
+	}
+
+	return m
+}
+
+func stripYAMLTombstones(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			stripYAMLTombstones(child)
+		}
+	case yaml.MappingNode:
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.ScalarNode &&
+				(value.ShortTag() == "!!null" || value.ShortTag() == "!!str" && value.Value == UnsetMarker) {
+				continue
+			}
+
+			stripYAMLTombstones(value)
+			content = append(content, key, value)
+		}
+		node.Content = content
+	}
+}
+
+// unsetPath resets the field at the path to its zero value, or deletes the
+// entry when the path ends in a map
+func unsetPath(v reflect.Value, path []string) {
+	parent, ok := v, true
+	if len(path) > 1 {
+		parent, _, ok = fieldByPath(v, path[:len(path)-1], false)
+	}
+	if !ok {
+		return
+	}
+
+	for parent.Kind() == reflect.Pointer {
+		if parent.IsNil() {
+			return
+		}
+		parent = parent.Elem()
+	}
+
+	last := path[len(path)-1]
+	switch parent.Kind() {
+	case reflect.Map:
+		if !parent.IsNil() && parent.Type().Key().Kind() == reflect.String {
+			parent.SetMapIndex(reflect.ValueOf(last).Convert(parent.Type().Key()), reflect.Value{})
+		}
+	case reflect.Struct:
+		if field := parent.FieldByName(last); field.IsValid() {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+}
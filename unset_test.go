@@ -0,0 +1,132 @@
+package flowconf_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/stretchr/testify/assert"
+)
+
+type unsetConfig struct {
+	Name     string
+	Port     int
+	Timeout  time.Duration
+	Hosts    []string `flowconf:"merge=append"`
+	Labels   map[string]string
+	Database *struct {
+		Host     string
+		Password string
+	}
+	Cache struct {
+		Size int
+		TTL  int
+	}
+}
+
+func TestBuilder_Build_unsetsValues(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	base := `
+Name = "app"
+Port = 8080
+Timeout = "5s"
+Hosts = [ "a", "b" ]
+Labels = { team = "core", tier = "1" }
+Database = { Host = "db", Password = "secret" }
+Cache = { Size = 10, TTL = 60 }
+`
+	tests := []struct {
+		name    string
+		overlay func() flowconf.Source
+		check   func(t *testing.T, config *unsetConfig)
+	}{
+		{
+			name: "json null and marker",
+			overlay: func() flowconf.Source {
+				return flowconf.NewSource("prod.json", flowconf.Json, io.NopCloser(strings.NewReader(`{
+	"Name": null,
+	"Port": "@unset",
+	"Hosts": null,
+	"Labels": {"tier": null},
+	"Database": null,
+	"Cache": {"TTL": "@unset", "Size": 20}
+}`)))
+			},
+			check: func(t *testing.T, config *unsetConfig) {
+				assert.Equal(t, "", config.Name)
+				assert.Equal(t, 0, config.Port)
+				assert.Equal(t, 5*time.Second, config.Timeout)
+				assert.Nil(t, config.Hosts)
+				assert.Equal(t, map[string]string{"team": "core"}, config.Labels)
+				assert.Nil(t, config.Database)
+				assert.Equal(t, 20, config.Cache.Size)
+				assert.Equal(t, 0, config.Cache.TTL)
+			},
+		},
+		{
+			name: "toml marker",
+			overlay: func() flowconf.Source {
+				return flowconf.NewSource("prod.toml", flowconf.Toml, io.NopCloser(strings.NewReader(`
+Port = "@unset"
+Timeout = "@unset"
+Database = { Password = "@unset" }
+Labels = { team = "@unset", owner = "bob" }
+`)))
+			},
+			check: func(t *testing.T, config *unsetConfig) {
+				assert.Equal(t, "app", config.Name)
+				assert.Equal(t, 0, config.Port)
+				assert.Equal(t, time.Duration(0), config.Timeout)
+				assert.Equal(t, "db", config.Database.Host)
+				assert.Equal(t, "", config.Database.Password)
+				assert.Equal(t, map[string]string{"tier": "1", "owner": "bob"}, config.Labels)
+			},
+		},
+		{
+			name: "yaml null and marker",
+			overlay: func() flowconf.Source {
+				return flowconf.NewSource("prod.yaml", flowconf.Yaml, io.NopCloser(strings.NewReader(`
+name: ~
+port: "@unset"
+cache:
+  ttl:
+  size: 30
+`)))
+			},
+			check: func(t *testing.T, config *unsetConfig) {
+				assert.Equal(t, "", config.Name)
+				assert.Equal(t, 0, config.Port)
+				assert.Equal(t, 30, config.Cache.Size)
+				assert.Equal(t, 0, config.Cache.TTL)
+			},
+		},
+		{
+			name: "environment marker",
+			overlay: func() flowconf.Source {
+				t.Setenv("FLOWCONF_TEST_PORT", "@unset")
+				t.Setenv("FLOWCONF_TEST_HOSTS", "@unset")
+				return flowconf.NewSourceFromEnv("FLOWCONF_TEST")
+			},
+			check: func(t *testing.T, config *unsetConfig) {
+				assert.Equal(t, "app", config.Name)
+				assert.Equal(t, 0, config.Port)
+				assert.Nil(t, config.Hosts)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				config := new(unsetConfig)
+				source := flowconf.NewSource("base.toml", flowconf.Toml, io.NopCloser(strings.NewReader(base)))
+
+				err := flowconf.NewBuilder(source, tt.overlay()).Build(config)
+
+				assert.NoError(t, err)
+				tt.check(t, config)
+			},
+		)
+	}
+}
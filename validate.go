@@ -0,0 +1,319 @@
+package flowconf
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator is implemented by a configuration, or a nested struct, checking
+// its own values once the configuration is built. The Validate method of an
+// embedded struct is only called through the struct embedding it
+type Validator interface {
+	Validate() error
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// rule is a rule of the `validate` tag, like min=1
+type rule struct {
+	name string
+	arg  string
+}
+
+// parseRules parses the `validate` tag, the rules are separated by commas.
+// The argument of regex is the rest of the tag so the expression can contain
+// commas
+func parseRules(tag string) ([]rule, error) {
+	var rules []rule
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, arg, hasArg := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "min", "max", "oneof", "regex":
+			if !hasArg {
+				return nil, fmt.Errorf("rule %s needs a value", name)
+			}
+		case "url", "hostport", "nonempty":
+			if hasArg {
+				return nil, fmt.Errorf("rule %s takes no value", name)
+			}
+		default:
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+
+		rules = append(rules, rule{name: name, arg: arg})
+	}
+
+	return rules, nil
+}
+
+// validate checks the rules of the `validate` tags and calls the Validate
+// method of the structs implementing Validator, it returns every violation.
+// The Validate method of v is only called when withValidator is true.
+// An invalid tag is returned as an error
+func validate(v reflect.Value, path string, withValidator bool) ([]Violation, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	validator, isValidator := asValidator(v)
+
+	var violations []Violation
+	switch v.Kind() {
+	case reflect.Struct:
+		if !isLeafType(v.Type()) {
+			t := v.Type()
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if !field.IsExported() {
+					continue
+				}
+
+				fieldPath := joinPath(path, field.Name)
+				if tag, ok := field.Tag.Lookup("validate"); ok {
+					rules, err := parseRules(tag)
+					if err != nil {
+						return nil, fmt.Errorf("invalid validate tag on %s, %w", fieldPath, err)
+					}
+
+					found, err := checkRules(v.Field(i), fieldPath, rules)
+					if err != nil {
+						return nil, fmt.Errorf("invalid validate tag on %s, %w", fieldPath, err)
+					}
+					violations = append(violations, found...)
+				}
+
+				// the Validate method of an embedded struct is promoted, it is
+				// called with the one of the struct, or by it when overridden
+				found, err := validate(v.Field(i), fieldPath, !(field.Anonymous && isValidator))
+				if err != nil {
+					return nil, err
+				}
+				violations = append(violations, found...)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			found, err := validate(v.Index(i), fmt.Sprintf("%s[%d]", path, i), true)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, found...)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, key := range keys {
+			found, err := validate(v.MapIndex(key), fmt.Sprintf("%s[%v]", path, key), true)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, found...)
+		}
+	}
+
+	if isValidator && withValidator {
+		if err := validator.Validate(); err != nil {
+			violations = append(violations, Violation{Field: path, Rule: "Validate", Message: err.Error()})
+		}
+	}
+
+	return violations, nil
+}
+
+// asValidator returns v as a Validator, with a pointer receiver if v is
+// addressable
+func asValidator(v reflect.Value) (Validator, bool) {
+	if v.CanAddr() && v.Addr().Type().Implements(validatorType) {
+		return v.Addr().Interface().(Validator), true
+	}
+	if v.Type().Implements(validatorType) {
+		return v.Interface().(Validator), true
+	}
+
+	return nil, false
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// checkRules checks the rules of a field. min, max and nonempty apply to the
+// field, the length of a string, slice or map. The other rules apply to each
+// element of a slice and skip the empty strings
+func checkRules(v reflect.Value, path string, rules []rule) ([]Violation, error) {
+	var violations []Violation
+	for _, r := range rules {
+		message, err := checkRule(v, r)
+		if err != nil {
+			return nil, err
+		}
+		if message != "" {
+			violations = append(violations, Violation{Field: path, Rule: r.name, Message: message})
+		}
+	}
+
+	return violations, nil
+}
+
+func checkRule(v reflect.Value, r rule) (string, error) {
+	if r.name == "nonempty" {
+		if v.IsZero() || (isSized(v.Kind()) && v.Len() == 0) {
+			return "must not be empty", nil
+		}
+		return "", nil
+	}
+
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	switch r.name {
+	case "min", "max":
+		cmp, err := compare(v, r.arg)
+		if err != nil {
+			return "", err
+		}
+
+		if r.name == "min" && cmp < 0 {
+			return "must be at least " + r.arg, nil
+		}
+		if r.name == "max" && cmp > 0 {
+			return "must be at most " + r.arg, nil
+		}
+		return "", nil
+	}
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			message, err := checkRule(v.Index(i), r)
+			if err != nil || message != "" {
+				return message, err
+			}
+		}
+		return "", nil
+	}
+
+	switch r.name {
+	case "oneof":
+		if v.Kind() == reflect.String && v.Len() == 0 {
+			return "", nil
+		}
+
+		str := fmt.Sprint(v.Interface())
+		for _, allowed := range strings.Fields(r.arg) {
+			if str == allowed {
+				return "", nil
+			}
+		}
+		return "must be one of " + r.arg, nil
+	}
+
+	if v.Kind() != reflect.String {
+		return "", fmt.Errorf("rule %s applies to strings, not %s", r.name, v.Type())
+	}
+	str := v.String()
+	if str == "" {
+		return "", nil
+	}
+
+	switch r.name {
+	case "url":
+		u, err := url.Parse(str)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be an absolute URL", nil
+		}
+	case "hostport":
+		_, port, err := net.SplitHostPort(str)
+		if err != nil {
+			return "must be a host:port address", nil
+		}
+		if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+			return "must be a host:port address", nil
+		}
+	case "regex":
+		reg, err := regexp.Compile(r.arg)
+		if err != nil {
+			return "", err
+		}
+		if !reg.MatchString(str) {
+			return "must match " + r.arg, nil
+		}
+	}
+
+	return "", nil
+}
+
+func isSized(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+}
+
+// compare compares the value to the bound. The length of a string, slice or
+// map is compared, any other value is compared to the bound converted to its
+// type, like "1s" for a time.Duration
+func compare(v reflect.Value, bound string) (int, error) {
+	if isSized(v.Kind()) {
+		n, err := strconv.Atoi(bound)
+		if err != nil {
+			return 0, err
+		}
+
+		length := v.Len()
+		if v.Kind() == reflect.String {
+			length = utf8.RuneCountInString(v.String())
+		}
+		return compareOrdered(length, n), nil
+	}
+
+	b := reflect.New(v.Type()).Elem()
+	if err := setFromString(b, bound, defaultSeparator); err != nil {
+		return 0, err
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(v.Int(), b.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return compareOrdered(v.Uint(), b.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(v.Float(), b.Float()), nil
+	}
+
+	return 0, fmt.Errorf("min and max do not apply to %s", v.Type())
+}
+
+func compareOrdered[T int | int64 | uint64 | float64](a T, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
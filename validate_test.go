@@ -0,0 +1,234 @@
+package flowconf_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SamuelTissot/flowconf"
+	"github.com/stretchr/testify/assert"
+)
+
+type validatedServer struct {
+	Address string `validate:"hostport"`
+	Weight  int    `validate:"min=1"`
+}
+
+type validatedDatabase struct {
+	User     string
+	Password string
+}
+
+func (database *validatedDatabase) Validate() error {
+	if database.User != "" && database.Password == "" {
+		return errors.New("a password is needed with a user")
+	}
+	return nil
+}
+
+type validatedConfig struct {
+	Port     int               `validate:"min=1,max=65535"`
+	Mode     string            `validate:"oneof=dev prod"`
+	Endpoint string            `validate:"url"`
+	Name     string            `validate:"nonempty,regex=^[a-z]{2,}$"`
+	Timeout  time.Duration     `validate:"min=1s,max=1m"`
+	Origins  []string          `validate:"min=1,url"`
+	Servers  []validatedServer `validate:"max=2"`
+	Database validatedDatabase
+	Optional *validatedServer
+}
+
+func (config validatedConfig) Validate() error {
+	if config.Mode == "prod" && config.Port == 8080 {
+		return errors.New("prod does not run on 8080")
+	}
+	return nil
+}
+
+func TestBuilder_Build_validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []flowconf.Violation
+	}{
+		{
+			name: "valid",
+			content: `{
+				"Port": 443, "Mode": "prod", "Endpoint": "https://example.com", "Name": "app", "Timeout": 10000000000,
+				"Origins": ["https://a.com"], "Servers": [{"Address": "db:5432", "Weight": 1}]
+			}`,
+		},
+		{
+			name: "every violation is reported",
+			content: `{
+				"Port": 8080, "Mode": "prod", "Endpoint": "example.com", "Name": "App", "Timeout": 120000000000,
+				"Origins": ["https://a.com", "b.com"],
+				"Servers": [{"Address": "db", "Weight": 1}, {"Address": "db:5432"}, {"Address": "db:5432", "Weight": 1}],
+				"Database": {"User": "app"}
+			}`,
+			want: []flowconf.Violation{
+				{Field: "Endpoint", Rule: "url", Message: "must be an absolute URL"},
+				{Field: "Name", Rule: "regex", Message: "must match ^[a-z]{2,}$"},
+				{Field: "Timeout", Rule: "max", Message: "must be at most 1m"},
+				{Field: "Origins", Rule: "url", Message: "must be an absolute URL"},
+				{Field: "Servers", Rule: "max", Message: "must be at most 2"},
+				{Field: "Servers[0].Address", Rule: "hostport", Message: "must be a host:port address"},
+				{Field: "Servers[1].Weight", Rule: "min", Message: "must be at least 1"},
+				{Field: "Database", Rule: "Validate", Message: "a password is needed with a user"},
+				{Field: "", Rule: "Validate", Message: "prod does not run on 8080"},
+			},
+		},
+		{
+			name:    "zero values",
+			content: `{}`,
+			want: []flowconf.Violation{
+				{Field: "Port", Rule: "min", Message: "must be at least 1"},
+				{Field: "Name", Rule: "nonempty", Message: "must not be empty"},
+				{Field: "Timeout", Rule: "min", Message: "must be at least 1s"},
+				{Field: "Origins", Rule: "min", Message: "must be at least 1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// /////////////////////// GIVEN ///////////////////////
+			source := flowconf.NewSource("config.json", flowconf.Json, io.NopCloser(strings.NewReader(tt.content)))
+
+			// /////////////////////// WHEN ///////////////////////
+			err := flowconf.NewBuilder(source).Build(new(validatedConfig))
+
+			// /////////////////////// THEN ///////////////////////
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *flowconf.ValidationError
+			if assert.True(t, errors.As(err, &validationErr)) {
+				assert.Equal(t, tt.want, validationErr.Violations)
+			}
+		})
+	}
+}
+
+func TestBuilder_Build_validationErrorMessage(t *testing.T) {
+	// /////////////////////// GIVEN ///////////////////////
+	var config struct {
+		Port int    `validate:"max=10"`
+		Mode string `validate:"oneof=a b"`
+	}
+	source := flowconf.NewSource("config.json", flowconf.Json, io.NopCloser(strings.NewReader(`{"Port": 11, "Mode": "c"}`)))
+
+	// /////////////////////// WHEN ///////////////////////
+	err := flowconf.NewBuilder(source).Build(&config)
+
+	// /////////////////////// THEN ///////////////////////
+	assert.EqualError(t, err, "invalid configuration: Port must be at most 10, Mode must be one of a b")
+}
+
+type ValidatedBase struct {
+	Port int
+}
+
+func (base ValidatedBase) Validate() error {
+	if base.Port == 0 {
+		return errors.New("bad port")
+	}
+	return nil
+}
+
+type validatedOverride struct {
+	ValidatedBase
+	Name string
+}
+
+func (config validatedOverride) Validate() error {
+	if config.Name == "" {
+		return errors.New("a name is needed")
+	}
+	return config.ValidatedBase.Validate()
+}
+
+func TestBuilder_Build_embeddedValidator(t *testing.T) {
+	tests := []struct {
+		name   string
+		config any
+		want   string
+	}{
+		{
+			name: "promoted",
+			config: &struct {
+				ValidatedBase
+				Name string
+			}{},
+			want: "invalid configuration: bad port",
+		},
+		{
+			name:   "overridden",
+			config: &validatedOverride{Name: "app"},
+			want:   "invalid configuration: bad port",
+		},
+		{
+			name: "nested in a field",
+			config: &struct {
+				Server struct {
+					ValidatedBase
+				}
+			}{},
+			want: "invalid configuration: Server bad port",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// /////////////////////// WHEN ///////////////////////
+			err := flowconf.NewBuilder().Build(tt.config)
+
+			// /////////////////////// THEN ///////////////////////
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestBuilder_Build_invalidValidateTag(t *testing.T) {
+	tests := []struct {
+		name   string
+		config any
+		want   string
+	}{
+		{
+			name: "unknown rule",
+			config: &struct {
+				Port int `validate:"positive"`
+			}{},
+			want: `invalid validate tag on Port, unknown rule "positive"`,
+		},
+		{
+			name: "missing value",
+			config: &struct {
+				Port int `validate:"min"`
+			}{},
+			want: "invalid validate tag on Port, rule min needs a value",
+		},
+		{
+			name: "string rule on a number",
+			config: &struct {
+				Port int `validate:"url"`
+			}{},
+			want: "invalid validate tag on Port, rule url applies to strings, not int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// /////////////////////// WHEN ///////////////////////
+			err := flowconf.NewBuilder().Build(tt.config)
+
+			// /////////////////////// THEN ///////////////////////
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package flowconf
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSeparator splits the elements of a slice written as a single string,
+// it can be changed per field with the `separator` struct tag
+const defaultSeparator = ","
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// separatorOf returns the separator used to split the string value of the field
+func separatorOf(field reflect.StructField) string {
+	if sep, ok := field.Tag.Lookup("separator"); ok && sep != "" {
+		return sep
+	}
+
+	return defaultSeparator
+}
+
+// isLeafType reports if a value of type t is set from a single string
+// instead of being walked into
+func isLeafType(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	return t.Kind() != reflect.Struct
+}
+
+// setFromString converts str to the type of v and sets it.
+// slices are split with sep and every element is converted on its own
+func setFromString(v reflect.Value, str string, sep string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(str, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(str, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(str, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := setFromString(elem.Elem(), str, sep); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(str))
+			return nil
+		}
+
+		var parts []string
+		if str != "" {
+			parts = strings.Split(str, sep)
+		}
+
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setFromString(slice.Index(i), strings.TrimSpace(part), sep); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type: %s", v.Type())
+	}
+
+	return nil
+}